@@ -2,12 +2,15 @@ package config
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/spf13/viper"
 )
 
 type Config struct {
-	Google GoogleConfig
+	Google  GoogleConfig
+	Comment CommentConfig
+	Retry   RetryConfig
 }
 
 type GoogleConfig struct {
@@ -16,6 +19,16 @@ type GoogleConfig struct {
 	TestDocID    string `mapstructure:"GOOGLE_TEST_DOC_ID"`
 }
 
+type CommentConfig struct {
+	AnchorStrategy string `mapstructure:"ANCHOR_STRATEGY"` // position, line, quoted_text or named_range (empty means position)
+}
+
+type RetryConfig struct {
+	MaxAttempts int           `mapstructure:"RETRY_MAX_ATTEMPTS"` // attempts per request including the first (0 means default, 1 disables retries)
+	BaseDelay   time.Duration `mapstructure:"RETRY_BASE_DELAY"`   // backoff before the first retry, e.g. 500ms (0 means default)
+	MaxDelay    time.Duration `mapstructure:"RETRY_MAX_DELAY"`    // upper bound of the delay between attempts, e.g. 30s (0 means default)
+}
+
 // Load loads configuration from .env file and environment variables
 func Load() (*Config, error) {
 	return LoadFromFile(".env")
@@ -45,6 +58,14 @@ func LoadFromFile(configFile string) (*Config, error) {
 			ClientSecret: v.GetString("GOOGLE_CLIENT_SECRET"),
 			TestDocID:    v.GetString("GOOGLE_TEST_DOC_ID"),
 		},
+		Comment: CommentConfig{
+			AnchorStrategy: v.GetString("ANCHOR_STRATEGY"),
+		},
+		Retry: RetryConfig{
+			MaxAttempts: v.GetInt("RETRY_MAX_ATTEMPTS"),
+			BaseDelay:   v.GetDuration("RETRY_BASE_DELAY"),
+			MaxDelay:    v.GetDuration("RETRY_MAX_DELAY"),
+		},
 	}
 
 	// 必須項目のバリデーション
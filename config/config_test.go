@@ -3,6 +3,7 @@ package config
 import (
 	"os"
 	"testing"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
 )
@@ -42,6 +43,44 @@ func TestLoad(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		{
+			name:       "anchor strategy from environment",
+			configFile: "../.env.test",
+			envVars: map[string]string{
+				"ANCHOR_STRATEGY": "quoted_text",
+			},
+			wantConfig: &Config{
+				Google: GoogleConfig{
+					ClientID:     "test-client-id",
+					ClientSecret: "test-client-secret",
+				},
+				Comment: CommentConfig{
+					AnchorStrategy: "quoted_text",
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name:       "retry settings from environment",
+			configFile: "../.env.test",
+			envVars: map[string]string{
+				"RETRY_MAX_ATTEMPTS": "3",
+				"RETRY_BASE_DELAY":   "250ms",
+				"RETRY_MAX_DELAY":    "10s",
+			},
+			wantConfig: &Config{
+				Google: GoogleConfig{
+					ClientID:     "test-client-id",
+					ClientSecret: "test-client-secret",
+				},
+				Retry: RetryConfig{
+					MaxAttempts: 3,
+					BaseDelay:   250 * time.Millisecond,
+					MaxDelay:    10 * time.Second,
+				},
+			},
+			wantErr: false,
+		},
 		{
 			name:       "missing client ID",
 			configFile: "nonexistent.env",
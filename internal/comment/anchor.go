@@ -0,0 +1,170 @@
+package comment
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// AnchorStatus describes how a comment ended up attached to the document
+type AnchorStatus string
+
+const (
+	AnchorStatusNone       AnchorStatus = "none"        // No quoted text or line was given
+	AnchorStatusAnchored   AnchorStatus = "anchored"    // The comment is anchored to the located text
+	AnchorStatusQuotedOnly AnchorStatus = "quoted_only" // The text was not located; only the quote is attached
+	AnchorStatusFailed     AnchorStatus = "failed"      // Locating the text or building the anchor failed
+)
+
+// AnchorResult is the outcome of resolving where a comment should be attached
+type AnchorResult struct {
+	Status     AnchorStatus
+	Reason     error         // Why the comment is not anchored, nil when anchored
+	Anchor     string        // Drive anchor JSON, empty when not anchored
+	QuotedText string        // Text attached as the quoted file content
+	Position   *TextPosition // Located position of the quoted text, if any
+	NamedRange string        // Name of the Docs named range created around the text, if any
+}
+
+// AnchorStrategy decides how a comment request is anchored to the document.
+// Strategies are looked up by name, so new approaches can be registered with WithAnchorStrategy
+// without changing CreateComment.
+type AnchorStrategy interface {
+	// Name identifies the strategy in CommentRequest.AnchorStrategy and configuration
+	Name() string
+	// Resolve computes the anchor for a request
+	Resolve(ctx context.Context, cm *CommentManager, req *CommentRequest) *AnchorResult
+}
+
+// Names of the built-in anchor strategies
+const (
+	AnchorStrategyPosition   = "position"
+	AnchorStrategyLine       = "line"
+	AnchorStrategyQuotedText = "quoted_text"
+)
+
+// DefaultAnchorStrategy is used when neither the request nor the manager selects a strategy
+const DefaultAnchorStrategy = AnchorStrategyPosition
+
+// defaultAnchorStrategies returns the built-in strategies
+func defaultAnchorStrategies() []AnchorStrategy {
+	return []AnchorStrategy{
+		PositionAnchorStrategy{},
+		LineAnchorStrategy{},
+		QuotedTextAnchorStrategy{},
+		NamedRangeAnchorStrategy{},
+	}
+}
+
+// PositionAnchorStrategy locates the quoted text and anchors to its Docs index range
+type PositionAnchorStrategy struct{}
+
+func (PositionAnchorStrategy) Name() string { return AnchorStrategyPosition }
+
+func (PositionAnchorStrategy) Resolve(ctx context.Context, cm *CommentManager, req *CommentRequest) *AnchorResult {
+	if req.QuotedText == "" {
+		return &AnchorResult{Status: AnchorStatusNone}
+	}
+
+	pos, err := cm.LocateText(ctx, req.FileID, req.textQuery())
+	if err != nil {
+		var ambiguous *AmbiguousTextError
+		if errors.Is(err, ErrTextNotFound) || errors.As(err, &ambiguous) {
+			return &AnchorResult{Status: AnchorStatusQuotedOnly, Reason: err, QuotedText: req.QuotedText}
+		}
+		return &AnchorResult{Status: AnchorStatusFailed, Reason: err, QuotedText: req.QuotedText}
+	}
+
+	// Drive anchors only address the body, so text in a header, footer or footnote cannot be anchored
+	if pos.SegmentID != "" {
+		return &AnchorResult{
+			Status:     AnchorStatusQuotedOnly,
+			Reason:     fmt.Errorf("text is in segment %q outside the document body and cannot be anchored", pos.SegmentID),
+			QuotedText: pos.MatchedText,
+			Position:   pos,
+		}
+	}
+
+	anchor, err := createAnchorJSONWithPosition(pos)
+	if err != nil {
+		return &AnchorResult{Status: AnchorStatusFailed, Reason: err, QuotedText: pos.MatchedText, Position: pos}
+	}
+
+	// Quote the text as it appears in the document
+	return &AnchorResult{Status: AnchorStatusAnchored, Anchor: anchor, QuotedText: pos.MatchedText, Position: pos}
+}
+
+// LineAnchorStrategy anchors to the index range of a line, as numbered by review.BuildLines
+type LineAnchorStrategy struct{}
+
+func (LineAnchorStrategy) Name() string { return AnchorStrategyLine }
+
+func (LineAnchorStrategy) Resolve(ctx context.Context, cm *CommentManager, req *CommentRequest) *AnchorResult {
+	if req.LineNumber <= 0 {
+		if req.QuotedText == "" {
+			return &AnchorResult{Status: AnchorStatusNone}
+		}
+		return &AnchorResult{
+			Status:     AnchorStatusQuotedOnly,
+			Reason:     fmt.Errorf("line number must be greater than 0 for line anchors"),
+			QuotedText: req.QuotedText,
+		}
+	}
+
+	// Resolve the line with the same definition fetch_google_doc uses for numbered output
+	line, err := cm.ResolveLine(ctx, req.FileID, req.LineNumber)
+	if err != nil {
+		return &AnchorResult{Status: AnchorStatusFailed, Reason: fmt.Errorf("failed to resolve line: %w", err), QuotedText: req.QuotedText}
+	}
+
+	// Fall back to the text of the line for display
+	quotedText := req.QuotedText
+	if quotedText == "" {
+		quotedText = line.Text
+	}
+
+	// Anchor to the index range of the resolved line; Drive's own "line" region counts lines differently
+	position := &TextPosition{StartIndex: line.StartIndex, EndIndex: line.EndIndex, MatchedText: line.Text, Confidence: 1}
+	anchor, err := createAnchorJSONWithPosition(position)
+	if err != nil {
+		return &AnchorResult{Status: AnchorStatusFailed, Reason: fmt.Errorf("failed to create anchor: %w", err), QuotedText: quotedText}
+	}
+
+	return &AnchorResult{
+		Status:     AnchorStatusAnchored,
+		Anchor:     anchor,
+		QuotedText: quotedText,
+		Position:   position,
+	}
+}
+
+// QuotedTextAnchorStrategy attaches the quoted text without an anchor
+type QuotedTextAnchorStrategy struct{}
+
+func (QuotedTextAnchorStrategy) Name() string { return AnchorStrategyQuotedText }
+
+func (QuotedTextAnchorStrategy) Resolve(ctx context.Context, cm *CommentManager, req *CommentRequest) *AnchorResult {
+	if req.QuotedText == "" {
+		return &AnchorResult{Status: AnchorStatusNone}
+	}
+
+	return &AnchorResult{
+		Status:     AnchorStatusQuotedOnly,
+		Reason:     fmt.Errorf("anchor strategy %q attaches the quoted text only", AnchorStrategyQuotedText),
+		QuotedText: req.QuotedText,
+	}
+}
+
+// anchorStrategy returns the strategy selected by the request, or the manager default
+func (cm *CommentManager) anchorStrategy(req *CommentRequest) (AnchorStrategy, error) {
+	name := req.AnchorStrategy
+	if name == "" {
+		name = cm.defaultStrategy
+	}
+
+	strategy, ok := cm.strategies[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown anchor strategy: %q", name)
+	}
+	return strategy, nil
+}
@@ -0,0 +1,291 @@
+package comment
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultBatchConcurrency is the number of comments posted at the same time
+	DefaultBatchConcurrency = 4
+	// DefaultBatchRate is the number of comments started per second. Drive allows a sustained rate
+	// of about 3 write requests per second per user, and anchoring also reads the document.
+	DefaultBatchRate = 3.0
+)
+
+// BatchOptions controls how CreateMultipleComments posts a batch of comments
+type BatchOptions struct {
+	// SkipDuplicates compares each request with the comments already on the document and skips it
+	// when a comment with the same quoted text and similar content exists.
+	SkipDuplicates bool
+	// DuplicateSimilarity is the content similarity required for a duplicate (optional, 0 means DefaultDuplicateSimilarity)
+	DuplicateSimilarity float64
+	// Concurrency is the number of comments posted at the same time (optional, 0 means DefaultBatchConcurrency)
+	Concurrency int
+	// RequestsPerSecond limits how many comments are started per second
+	// (optional, 0 means DefaultBatchRate, negative means no limit)
+	RequestsPerSecond float64
+}
+
+// concurrency returns the number of workers posting comments
+func (o *BatchOptions) concurrency() int {
+	if o.Concurrency > 0 {
+		return o.Concurrency
+	}
+	return DefaultBatchConcurrency
+}
+
+// requestsPerSecond returns the rate limit of the batch, 0 meaning no limit
+func (o *BatchOptions) requestsPerSecond() float64 {
+	switch {
+	case o.RequestsPerSecond < 0:
+		return 0
+	case o.RequestsPerSecond == 0:
+		return DefaultBatchRate
+	default:
+		return o.RequestsPerSecond
+	}
+}
+
+// BatchItem is the outcome of one request of a batch. Exactly one of Response, Skipped and Err is set.
+type BatchItem struct {
+	Index    int // Index of the request in the batch
+	Request  *CommentRequest
+	Response *CommentResponse
+	Skipped  *SkippedComment
+	Err      error
+}
+
+// BatchResult is the result of posting a batch of comments
+type BatchResult struct {
+	Items     []*BatchItem       // One item per request, in request order
+	Responses []*CommentResponse // Posted comments, in request order
+	Skipped   []*SkippedComment  // Skipped duplicates, in request order
+}
+
+// BatchError is returned when some requests of a batch failed. The other requests were still
+// posted, so only Requests() needs to be retried.
+type BatchError struct {
+	Failed []*BatchItem
+}
+
+func (e *BatchError) Error() string {
+	return fmt.Sprintf("failed to create %d comments: %v", len(e.Failed), errors.Join(e.Unwrap()...))
+}
+
+// Unwrap returns the error of each failed item, so errors.Is and errors.As see them
+func (e *BatchError) Unwrap() []error {
+	errs := make([]error, 0, len(e.Failed))
+	for _, item := range e.Failed {
+		errs = append(errs, fmt.Errorf("comment %d: %w", item.Index, item.Err))
+	}
+	return errs
+}
+
+// Requests returns the failed requests, to be passed to CreateMultipleComments again
+func (e *BatchError) Requests() []*CommentRequest {
+	requests := make([]*CommentRequest, 0, len(e.Failed))
+	for _, item := range e.Failed {
+		requests = append(requests, item.Request)
+	}
+	return requests
+}
+
+// batchComments lists the comments of each document once per batch, for finding both the comments
+// an earlier call already posted and the duplicates of a request
+type batchComments struct {
+	cm       *CommentManager
+	comments map[string][]*Comment
+}
+
+// newBatchComments creates an empty batchComments
+func newBatchComments(cm *CommentManager) *batchComments {
+	return &batchComments{cm: cm, comments: make(map[string][]*Comment)}
+}
+
+// list returns the comments of a document, listing them on first use
+func (b *batchComments) list(ctx context.Context, fileID string) ([]*Comment, error) {
+	if comments, ok := b.comments[fileID]; ok {
+		return comments, nil
+	}
+
+	comments, err := b.cm.ListComments(ctx, fileID, nil)
+	if err != nil {
+		return nil, err
+	}
+	b.comments[fileID] = comments
+	return comments, nil
+}
+
+// add records a comment to be posted in this batch
+func (b *batchComments) add(fileID string, c *Comment) {
+	b.comments[fileID] = append(b.comments[fileID], c)
+}
+
+// CreateMultipleComments creates multiple comments in batch.
+// With opts.SkipDuplicates, requests equivalent to an existing comment are skipped and reported in the result.
+// Comments are posted concurrently within the limits of opts. A failed request does not stop the
+// others; the result holds every item and the returned *BatchError lists the failed ones.
+// Requests without an IdempotencyKey are given one, so when the failed requests are passed again,
+// comments Drive accepted despite an error are found and not posted twice.
+func (cm *CommentManager) CreateMultipleComments(ctx context.Context, requests []*CommentRequest, opts *BatchOptions) (*BatchResult, error) {
+	if opts == nil {
+		opts = &BatchOptions{}
+	}
+
+	items := make([]*BatchItem, len(requests))
+	for i, req := range requests {
+		items[i] = &BatchItem{Index: i, Request: req}
+	}
+
+	// Requests that already have an idempotency key may have been posted by an earlier call
+	comments := newBatchComments(cm)
+	for _, item := range items {
+		if item.Request.IdempotencyKey == "" {
+			key, err := NewIdempotencyKey()
+			if err != nil {
+				item.Err = err
+				continue
+			}
+			item.Request.IdempotencyKey = key
+			continue
+		}
+
+		listed, err := comments.list(ctx, item.Request.FileID)
+		if err != nil {
+			item.Err = fmt.Errorf("failed to check posted comments: %w", err)
+			continue
+		}
+		if c := postedComment(listed, item.Request.IdempotencyKey); c != nil {
+			item.Response = reusedResponse(c)
+		}
+	}
+
+	// Duplicates are checked in request order before posting, so a request is compared with
+	// the earlier requests of the batch even though they are posted concurrently
+	pending := make([]*Comment, len(requests))
+	if opts.SkipDuplicates {
+		duplicates := newDuplicateFinder(comments, opts)
+		for _, item := range items {
+			if item.Err != nil || item.Response != nil {
+				continue
+			}
+			duplicate, score, err := duplicates.find(ctx, item.Request)
+			if err != nil {
+				item.Err = fmt.Errorf("failed to check duplicates: %w", err)
+				continue
+			}
+			if duplicate != nil {
+				item.Skipped = &SkippedComment{Index: item.Index, Request: item.Request, Duplicate: duplicate, Similarity: score}
+				continue
+			}
+			pending[item.Index] = duplicates.add(item.Request)
+		}
+	}
+
+	limiter := newRateLimiter(opts.requestsPerSecond(), opts.concurrency())
+	workers := make(chan struct{}, opts.concurrency())
+	var wg sync.WaitGroup
+
+	for _, item := range items {
+		if item.Err != nil || item.Skipped != nil || item.Response != nil {
+			continue
+		}
+
+		workers <- struct{}{}
+		if err := limiter.Wait(ctx); err != nil {
+			<-workers
+			item.Err = err
+			continue
+		}
+
+		wg.Go(func() {
+			defer func() { <-workers }()
+			item.Response, item.Err = cm.createComment(ctx, item.Request)
+			if c := pending[item.Index]; c != nil && item.Err == nil {
+				c.ID = item.Response.CommentID
+				c.Content = item.Response.Content
+				c.CreatedAt = item.Response.CreatedAt
+			}
+		})
+	}
+	wg.Wait()
+
+	result := &BatchResult{Items: items, Responses: make([]*CommentResponse, 0, len(items))}
+	var failed []*BatchItem
+	for _, item := range items {
+		switch {
+		case item.Err != nil:
+			failed = append(failed, item)
+		case item.Skipped != nil:
+			result.Skipped = append(result.Skipped, item.Skipped)
+		default:
+			result.Responses = append(result.Responses, item.Response)
+		}
+	}
+
+	if len(failed) > 0 {
+		return result, &BatchError{Failed: failed}
+	}
+
+	return result, nil
+}
+
+// createComment posts a single request of a batch
+func (cm *CommentManager) createComment(ctx context.Context, req *CommentRequest) (*CommentResponse, error) {
+	// Use anchored comment if line number is specified
+	if req.LineNumber > 0 {
+		return cm.CreateAnchoredComment(ctx, req)
+	}
+	return cm.CreateComment(ctx, req)
+}
+
+// rateLimiter is a token bucket limiting how often Wait returns
+type rateLimiter struct {
+	mu     sync.Mutex
+	rate   float64 // Tokens added per second, 0 means no limit
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+// newRateLimiter creates a rateLimiter allowing rate events per second with the given burst
+func newRateLimiter(rate float64, burst int) *rateLimiter {
+	return &rateLimiter{rate: rate, burst: float64(burst), tokens: float64(burst), last: time.Now()}
+}
+
+// Wait blocks until a token is available or ctx is done
+func (l *rateLimiter) Wait(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if l.rate <= 0 {
+		return nil
+	}
+
+	l.mu.Lock()
+	now := time.Now()
+	l.tokens = math.Min(l.burst, l.tokens+now.Sub(l.last).Seconds()*l.rate)
+	l.last = now
+	l.tokens--
+	wait := time.Duration(-l.tokens / l.rate * float64(time.Second))
+	l.mu.Unlock()
+
+	if wait <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
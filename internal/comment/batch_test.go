@@ -0,0 +1,230 @@
+package comment
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/api/docs/v1"
+	"google.golang.org/api/googleapi"
+)
+
+func TestCreateMultipleComments_Concurrent(t *testing.T) {
+	var (
+		mu          sync.Mutex
+		inFlight    int
+		maxInFlight int
+	)
+	cm := newTestCommentManager(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/drive/v3/files/test-file-id/comments" {
+			http.NotFound(w, r)
+			return
+		}
+
+		mu.Lock()
+		inFlight++
+		maxInFlight = max(maxInFlight, inFlight)
+		mu.Unlock()
+
+		time.Sleep(20 * time.Millisecond)
+
+		mu.Lock()
+		inFlight--
+		mu.Unlock()
+
+		var body map[string]any
+		json.NewDecoder(r.Body).Decode(&body)
+		content, _ := body["content"].(string)
+
+		// Content "fail N" makes the request fail
+		if strings.HasPrefix(content, "fail") {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]any{"error": map[string]any{"code": http.StatusBadRequest, "message": "bad request"}})
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]any{"id": "id-" + stripSignature(content), "content": content})
+	}))
+
+	requests := make([]*CommentRequest, 0, 10)
+	for i := range 10 {
+		content := fmt.Sprintf("ok %d", i)
+		if i == 3 || i == 7 {
+			content = fmt.Sprintf("fail %d", i)
+		}
+		requests = append(requests, &CommentRequest{FileID: "test-file-id", Content: content, AnchorStrategy: AnchorStrategyQuotedText})
+	}
+
+	result, err := cm.CreateMultipleComments(context.Background(), requests, &BatchOptions{Concurrency: 3, RequestsPerSecond: -1})
+
+	var batchErr *BatchError
+	if !errors.As(err, &batchErr) {
+		t.Fatalf("CreateMultipleComments() error = %v, want *BatchError", err)
+	}
+	var apiErr *googleapi.Error
+	if !errors.As(err, &apiErr) || apiErr.Code != http.StatusBadRequest {
+		t.Errorf("CreateMultipleComments() error = %v, want wrapped googleapi.Error 400", err)
+	}
+
+	var failed []int
+	for _, item := range batchErr.Failed {
+		failed = append(failed, item.Index)
+	}
+	if diff := cmp.Diff([]int{3, 7}, failed); diff != "" {
+		t.Errorf("failed items mismatch (-want +got):\n%s", diff)
+	}
+	if got := batchErr.Requests(); len(got) != 2 || got[0] != requests[3] || got[1] != requests[7] {
+		t.Errorf("Requests() = %v, want requests 3 and 7", got)
+	}
+
+	if len(result.Items) != len(requests) {
+		t.Fatalf("got %d items, want %d", len(result.Items), len(requests))
+	}
+	var ids []string
+	for _, resp := range result.Responses {
+		ids = append(ids, resp.CommentID)
+	}
+	wantIDs := []string{"id-ok 0", "id-ok 1", "id-ok 2", "id-ok 4", "id-ok 5", "id-ok 6", "id-ok 8", "id-ok 9"}
+	if diff := cmp.Diff(wantIDs, ids); diff != "" {
+		t.Errorf("responses mismatch (-want +got):\n%s", diff)
+	}
+	for i, item := range result.Items {
+		if item.Index != i || item.Request != requests[i] {
+			t.Errorf("item %d = {Index: %d, Request: %p}, want {Index: %d, Request: %p}", i, item.Index, item.Request, i, requests[i])
+		}
+		if (item.Err != nil) == (item.Response != nil) {
+			t.Errorf("item %d has Err = %v and Response = %v, want exactly one", i, item.Err, item.Response)
+		}
+	}
+
+	if maxInFlight > 3 {
+		t.Errorf("max concurrent requests = %d, want at most 3", maxInFlight)
+	}
+	if maxInFlight < 2 {
+		t.Errorf("max concurrent requests = %d, want requests to run concurrently", maxInFlight)
+	}
+}
+
+func TestRateLimiter(t *testing.T) {
+	tests := []struct {
+		name    string
+		rate    float64
+		burst   int
+		waits   int
+		minTime time.Duration
+		maxTime time.Duration
+	}{
+		{
+			name:    "burst is not delayed",
+			rate:    10,
+			burst:   3,
+			waits:   3,
+			maxTime: 50 * time.Millisecond,
+		},
+		{
+			name:    "waits beyond burst are spaced by the rate",
+			rate:    50,
+			burst:   1,
+			waits:   6,
+			minTime: 90 * time.Millisecond,
+		},
+		{
+			name:    "no limit",
+			rate:    0,
+			burst:   1,
+			waits:   100,
+			maxTime: 50 * time.Millisecond,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			limiter := newRateLimiter(tt.rate, tt.burst)
+
+			start := time.Now()
+			for range tt.waits {
+				if err := limiter.Wait(context.Background()); err != nil {
+					t.Fatalf("Wait() error = %v", err)
+				}
+			}
+			elapsed := time.Since(start)
+
+			if elapsed < tt.minTime {
+				t.Errorf("elapsed = %v, want at least %v", elapsed, tt.minTime)
+			}
+			if tt.maxTime > 0 && elapsed > tt.maxTime {
+				t.Errorf("elapsed = %v, want at most %v", elapsed, tt.maxTime)
+			}
+		})
+	}
+}
+
+func TestRateLimiter_ContextCanceled(t *testing.T) {
+	limiter := newRateLimiter(0.1, 1)
+	if err := limiter.Wait(context.Background()); err != nil {
+		t.Fatalf("Wait() error = %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	if err := limiter.Wait(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Wait() error = %v, want %v", err, context.DeadlineExceeded)
+	}
+}
+
+func TestCreateMultipleComments_FetchesDocumentOnce(t *testing.T) {
+	var (
+		mu      sync.Mutex
+		fetches int
+	)
+	cm := newTestCommentManager(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/v1/documents/test-file-id":
+			if r.URL.Query().Get("fields") == "revisionId" {
+				json.NewEncoder(w).Encode(&docs.Document{RevisionId: "rev-1"})
+				return
+			}
+			mu.Lock()
+			fetches++
+			mu.Unlock()
+			json.NewEncoder(w).Encode(&docs.Document{
+				RevisionId: "rev-1",
+				Body:       &docs.Body{Content: []*docs.StructuralElement{textParagraph(1, "alpha beta gamma delta\n")}},
+			})
+		case r.Method == http.MethodPost && r.URL.Path == "/drive/v3/files/test-file-id/comments":
+			json.NewEncoder(w).Encode(map[string]any{"id": "comment"})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+
+	requests := make([]*CommentRequest, 0, 8)
+	for _, word := range []string{"alpha", "beta", "gamma", "delta", "alpha", "beta", "gamma", "delta"} {
+		requests = append(requests, &CommentRequest{FileID: "test-file-id", Content: word, QuotedText: word, Occurrence: 1})
+	}
+
+	result, err := cm.CreateMultipleComments(context.Background(), requests, &BatchOptions{RequestsPerSecond: -1})
+	if err != nil {
+		t.Fatalf("CreateMultipleComments() error = %v", err)
+	}
+	for _, resp := range result.Responses {
+		if resp.AnchorStatus != AnchorStatusAnchored {
+			t.Errorf("comment %q not anchored: %s", resp.MatchedText, resp.AnchorReason)
+		}
+	}
+
+	if fetches != 1 {
+		t.Errorf("document fetched %d times, want 1", fetches)
+	}
+}
@@ -5,8 +5,10 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
-	"strings"
+	"time"
 
+	"github.com/takeuchi-shogo/google-doc-review/internal/retry"
+	"github.com/takeuchi-shogo/google-doc-review/internal/review"
 	"google.golang.org/api/docs/v1"
 	"google.golang.org/api/drive/v3"
 	"google.golang.org/api/option"
@@ -17,10 +19,50 @@ type CommentManager struct {
 	client       *http.Client
 	driveService *drive.Service
 	docsService  *docs.Service
+	documents    *review.DocumentCache
+
+	strategies      map[string]AnchorStrategy
+	defaultStrategy string
+
+	retry retry.Config
+}
+
+// Option configures a CommentManager
+type Option func(*CommentManager)
+
+// WithAnchorStrategy registers an anchor strategy, replacing a built-in one with the same name
+func WithAnchorStrategy(strategy AnchorStrategy) Option {
+	return func(cm *CommentManager) {
+		cm.strategies[strategy.Name()] = strategy
+	}
+}
+
+// WithDefaultAnchorStrategy selects the strategy used when a request does not name one
+func WithDefaultAnchorStrategy(name string) Option {
+	return func(cm *CommentManager) {
+		if name != "" {
+			cm.defaultStrategy = name
+		}
+	}
+}
+
+// WithRetry configures how comment creation is retried. Posting a comment is only retried for
+// requests with an IdempotencyKey; other requests are retried by the HTTP client if it is set up to.
+func WithRetry(cfg retry.Config) Option {
+	return func(cm *CommentManager) {
+		cm.retry = cfg
+	}
+}
+
+// WithDocumentCache makes the manager share a document cache, e.g. with a review.GoogleDocFetcher
+func WithDocumentCache(cache *review.DocumentCache) Option {
+	return func(cm *CommentManager) {
+		cm.documents = cache
+	}
 }
 
 // NewCommentManager creates a new CommentManager
-func NewCommentManager(client *http.Client) (*CommentManager, error) {
+func NewCommentManager(client *http.Client, opts ...Option) (*CommentManager, error) {
 	ctx := context.Background()
 	driveService, err := drive.NewService(ctx, option.WithHTTPClient(client))
 	if err != nil {
@@ -32,11 +74,34 @@ func NewCommentManager(client *http.Client) (*CommentManager, error) {
 		return nil, fmt.Errorf("failed to create Docs service: %w", err)
 	}
 
-	return &CommentManager{
-		client:       client,
-		driveService: driveService,
-		docsService:  docsService,
-	}, nil
+	return NewCommentManagerWithServices(client, driveService, docsService, opts...)
+}
+
+// NewCommentManagerWithServices creates a CommentManager using existing Drive and Docs services
+func NewCommentManagerWithServices(client *http.Client, driveService *drive.Service, docsService *docs.Service, opts ...Option) (*CommentManager, error) {
+	cm := &CommentManager{
+		client:          client,
+		driveService:    driveService,
+		docsService:     docsService,
+		strategies:      make(map[string]AnchorStrategy),
+		defaultStrategy: DefaultAnchorStrategy,
+	}
+
+	for _, strategy := range defaultAnchorStrategies() {
+		cm.strategies[strategy.Name()] = strategy
+	}
+	for _, opt := range opts {
+		opt(cm)
+	}
+	if cm.documents == nil {
+		cm.documents = review.NewDocumentCache(docsService, 0)
+	}
+
+	if _, ok := cm.strategies[cm.defaultStrategy]; !ok {
+		return nil, fmt.Errorf("unknown anchor strategy: %q", cm.defaultStrategy)
+	}
+
+	return cm, nil
 }
 
 // CommentRequest represents a request to create a comment
@@ -46,6 +111,32 @@ type CommentRequest struct {
 	QuotedText string // Text to quote (optional)
 	LineNumber int    // Line number for anchored comment (optional, 0 means no anchor)
 	LineLength int    // Length of the line selection (optional)
+
+	// Disambiguation when QuotedText occurs several times (optional)
+	Occurrence    int    // 1-based occurrence of QuotedText to anchor to
+	ContextBefore string // Text immediately before QuotedText
+	ContextAfter  string // Text immediately after QuotedText
+
+	MinSimilarity float64 // Similarity required to accept a normalized or fuzzy match (optional, 0 means DefaultMinSimilarity)
+	Strict        bool    // Refuse to post the comment when QuotedText cannot be anchored
+
+	AnchorStrategy string // Name of the AnchorStrategy to use (optional, defaults to the manager setting)
+
+	// IdempotencyKey is embedded invisibly in the comment (optional). When posting fails with a
+	// transient error, the comments are searched for the key before posting again, so a comment
+	// Drive accepted despite the error is not posted twice.
+	IdempotencyKey string
+}
+
+// textQuery returns the query used to locate the quoted text of the request
+func (req *CommentRequest) textQuery() *TextQuery {
+	return &TextQuery{
+		Text:          req.QuotedText,
+		Occurrence:    req.Occurrence,
+		ContextBefore: req.ContextBefore,
+		ContextAfter:  req.ContextAfter,
+		MinSimilarity: req.MinSimilarity,
+	}
 }
 
 // CommentResponse represents the result of creating a comment
@@ -54,49 +145,38 @@ type CommentResponse struct {
 	Content   string
 	Anchor    string
 	CreatedAt string
+
+	MatchedText string  // Document text the quote was matched to
+	Confidence  float64 // Similarity between QuotedText and MatchedText, 1 for an exact match
+
+	AnchorStatus AnchorStatus // Outcome of anchoring the comment
+	AnchorReason string       // Why the comment is not anchored, empty when anchored
+
+	NamedRange string // Name of the Docs named range marking the quoted text, empty if none
+
+	Reused bool // The comment had already been posted by an earlier attempt with the same IdempotencyKey
 }
 
-// CreateComment creates a comment on a Google Doc with automatic anchor if quoted text is provided
+// CreateComment creates a comment on a Google Doc, anchored by the selected AnchorStrategy.
+// The response reports whether the anchor was attached; in strict mode the comment is not posted
+// unless it can be anchored.
 func (cm *CommentManager) CreateComment(ctx context.Context, req *CommentRequest) (*CommentResponse, error) {
-	comment := &drive.Comment{
-		Content: req.Content,
+	strategy, err := cm.anchorStrategy(req)
+	if err != nil {
+		return nil, err
 	}
 
-	// If quoted text is provided, try to find its position and create an anchor
-	if req.QuotedText != "" {
-		pos, err := cm.FindTextPosition(ctx, req.FileID, req.QuotedText)
-		if err == nil {
-			// Try creating anchor with position
-			anchor, err := createAnchorJSONWithPosition(pos)
-			if err == nil {
-				comment.Anchor = anchor
-			}
-		}
-
-		// Also add quoted text for display
-		comment.QuotedFileContent = &drive.CommentQuotedFileContent{
-			MimeType: "text/plain",
-			Value:    req.QuotedText,
-		}
+	result := strategy.Resolve(ctx, cm, req)
+	if req.Strict && result.Status != AnchorStatusAnchored && result.Status != AnchorStatusNone {
+		return nil, fmt.Errorf("failed to anchor comment: %w", result.Reason)
 	}
 
-	// Create the comment
-	createdComment, err := cm.driveService.Comments.
-		Create(req.FileID, comment).
-		Context(ctx).
-		Fields("id,content,createdTime,anchor,quotedFileContent").
-		Do()
-
+	resp, err := cm.postComment(ctx, req, result)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create comment: %w", err)
 	}
 
-	return &CommentResponse{
-		CommentID: createdComment.Id,
-		Content:   createdComment.Content,
-		Anchor:    createdComment.Anchor,
-		CreatedAt: createdComment.CreatedTime,
-	}, nil
+	return resp, nil
 }
 
 // CreateAnchoredComment creates an anchored comment on a specific line in a Google Doc
@@ -105,88 +185,277 @@ func (cm *CommentManager) CreateAnchoredComment(ctx context.Context, req *Commen
 		return nil, fmt.Errorf("line number must be greater than 0 for anchored comments")
 	}
 
-	// Create anchor JSON structure using line number
-	anchor, err := createAnchorJSON(req.LineNumber)
+	result := LineAnchorStrategy{}.Resolve(ctx, cm, req)
+	if result.Status != AnchorStatusAnchored {
+		return nil, result.Reason
+	}
+
+	resp, err := cm.postComment(ctx, req, result)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create anchor: %w", err)
+		return nil, fmt.Errorf("failed to create anchored comment: %w", err)
+	}
+
+	return resp, nil
+}
+
+// postComment posts a comment with the resolved anchor and quoted text
+func (cm *CommentManager) postComment(ctx context.Context, req *CommentRequest, result *AnchorResult) (*CommentResponse, error) {
+	if err := validateIdempotencyKey(req.IdempotencyKey); err != nil {
+		return nil, err
 	}
 
 	comment := &drive.Comment{
-		Content: req.Content,
-		Anchor:  anchor,
+		Content: commentBody(req.Content, result.NamedRange, req.IdempotencyKey),
+		Anchor:  result.Anchor,
 	}
 
-	// Add quoted text for display if provided
-	if req.QuotedText != "" {
+	// Add quoted text for display
+	if result.QuotedText != "" {
 		comment.QuotedFileContent = &drive.CommentQuotedFileContent{
 			MimeType: "text/plain",
-			Value:    req.QuotedText,
+			Value:    result.QuotedText,
 		}
 	}
 
-	// Create the comment
-	createdComment, err := cm.driveService.Comments.
-		Create(req.FileID, comment).
-		Context(ctx).
-		Fields("id,content,createdTime,anchor,quotedFileContent").
-		Do()
+	// Without an idempotency key a retry could post the comment twice, so it is attempted once
+	retryConfig := cm.retry
+	if req.IdempotencyKey == "" {
+		retryConfig.MaxAttempts = 1
+	}
+
+	// Create the comment, looking for a comment posted by a failed attempt before each retry
+	var createdComment *drive.Comment
+	var posted *Comment
+	firstAttempt := time.Now()
+	err := retry.Do(ctx, retryConfig, func(attempt int) error {
+		if attempt > 1 {
+			var err error
+			posted, err = cm.findPostedComment(ctx, req.FileID, req.IdempotencyKey, firstAttempt.Add(-recentCommentWindow))
+			if err != nil || posted != nil {
+				return err
+			}
+		}
+
+		var err error
+		createdComment, err = cm.driveService.Comments.
+			Create(req.FileID, comment).
+			Context(ctx).
+			Fields("id,content,createdTime,anchor,quotedFileContent").
+			Do()
+		return err
+	})
 
 	if err != nil {
-		return nil, fmt.Errorf("failed to create anchored comment: %w", err)
+		return nil, err
 	}
 
-	return &CommentResponse{
-		CommentID: createdComment.Id,
-		Content:   createdComment.Content,
-		Anchor:    createdComment.Anchor,
-		CreatedAt: createdComment.CreatedTime,
-	}, nil
+	if posted != nil {
+		resp := reusedResponse(posted)
+		resp.AnchorStatus = result.Status
+		resp.NamedRange = result.NamedRange
+		return resp, nil
+	}
+
+	resp := &CommentResponse{
+		CommentID:    createdComment.Id,
+		Content:      createdComment.Content,
+		Anchor:       createdComment.Anchor,
+		CreatedAt:    createdComment.CreatedTime,
+		AnchorStatus: result.Status,
+		NamedRange:   result.NamedRange,
+	}
+	if result.Position != nil {
+		resp.MatchedText = result.Position.MatchedText
+		resp.Confidence = result.Position.Confidence
+	}
+	if result.Reason != nil {
+		resp.AnchorReason = result.Reason.Error()
+	}
+
+	return resp, nil
 }
 
-// CreateMultipleComments creates multiple comments in batch
-func (cm *CommentManager) CreateMultipleComments(ctx context.Context, requests []*CommentRequest) ([]*CommentResponse, error) {
-	responses := make([]*CommentResponse, 0, len(requests))
-	errors := make([]error, 0)
+// commentBody returns the body posted for a comment: the content followed by the named range note,
+// the idempotency marker and the signature
+func commentBody(content, namedRange, idempotencyKey string) string {
+	if namedRange != "" {
+		// Record the named range in the body so the finding can be located after edits
+		content += "\n\n" + namedRangeNote(namedRange)
+	}
+	return signContent(content + idempotencyMarker(idempotencyKey))
+}
 
-	for i, req := range requests {
-		var resp *CommentResponse
-		var err error
+// ListCommentsOptions controls how comments are listed
+type ListCommentsOptions struct {
+	PageSize          int64  // Number of comments per page (optional, 0 means API default)
+	IncludeDeleted    bool   // Include deleted comments and replies
+	StartModifiedTime string // Only return comments modified after this RFC 3339 time (optional)
+}
 
-		// Use anchored comment if line number is specified
-		if req.LineNumber > 0 {
-			resp, err = cm.CreateAnchoredComment(ctx, req)
-		} else {
-			resp, err = cm.CreateComment(ctx, req)
-		}
+// Author represents the user who wrote a comment or reply
+type Author struct {
+	DisplayName  string `json:"display_name"`
+	EmailAddress string `json:"email_address,omitempty"`
+	Me           bool   `json:"me"`
+}
+
+// Name returns the display name of the author, falling back to the email address
+func (a Author) Name() string {
+	if a.DisplayName != "" {
+		return a.DisplayName
+	}
+	return a.EmailAddress
+}
+
+// Comment represents a comment thread on a Google Doc
+type Comment struct {
+	ID         string  `json:"id"`
+	Author     Author  `json:"author"`
+	Content    string  `json:"content"`
+	QuotedText string  `json:"quoted_text,omitempty"`
+	Anchor     string  `json:"anchor,omitempty"`
+	CreatedAt  string  `json:"created_at"`
+	ModifiedAt string  `json:"modified_at,omitempty"`
+	Resolved   bool    `json:"resolved"`
+	Deleted    bool    `json:"deleted,omitempty"`
+	Replies    []Reply `json:"replies"`
+
+	IdempotencyKey string `json:"idempotency_key,omitempty"` // Key the comment was posted with, see CommentRequest.IdempotencyKey
+}
+
+// Reply represents a reply in a comment thread
+type Reply struct {
+	ID         string `json:"id"`
+	Author     Author `json:"author"`
+	Content    string `json:"content"`
+	Action     string `json:"action,omitempty"` // "resolve" or "reopen" when the reply changed the thread state
+	CreatedAt  string `json:"created_at"`
+	ModifiedAt string `json:"modified_at,omitempty"`
+	Deleted    bool   `json:"deleted,omitempty"`
+}
 
-		if err != nil {
-			errors = append(errors, fmt.Errorf("comment %d: %w", i, err))
-			continue
+// commentFields is the partial response selector shared by comment reads
+const commentFields = "id,content,createdTime,modifiedTime,anchor,quotedFileContent,author,resolved,deleted,replies(id,content,createdTime,modifiedTime,author,action,deleted)"
+
+// ListComments lists all comments on a Google Doc, following every result page
+func (cm *CommentManager) ListComments(ctx context.Context, fileID string, opts *ListCommentsOptions) ([]*Comment, error) {
+	if opts == nil {
+		opts = &ListCommentsOptions{}
+	}
+
+	call := cm.driveService.Comments.
+		List(fileID).
+		IncludeDeleted(opts.IncludeDeleted).
+		Fields("nextPageToken", "comments("+commentFields+")")
+
+	if opts.PageSize > 0 {
+		call = call.PageSize(opts.PageSize)
+	}
+	if opts.StartModifiedTime != "" {
+		call = call.StartModifiedTime(opts.StartModifiedTime)
+	}
+
+	comments := make([]*Comment, 0)
+	err := call.Pages(ctx, func(page *drive.CommentList) error {
+		for _, c := range page.Comments {
+			comments = append(comments, newComment(c))
 		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list comments: %w", err)
+	}
+
+	return comments, nil
+}
 
-		responses = append(responses, resp)
+// newComment converts a Drive comment into a Comment
+func newComment(c *drive.Comment) *Comment {
+	comment := &Comment{
+		ID:         c.Id,
+		Author:     newAuthor(c.Author),
+		Content:    c.Content,
+		Anchor:     c.Anchor,
+		CreatedAt:  c.CreatedTime,
+		ModifiedAt: c.ModifiedTime,
+		Resolved:   c.Resolved,
+		Deleted:    c.Deleted,
+		Replies:    make([]Reply, 0, len(c.Replies)),
 	}
 
-	if len(errors) > 0 {
-		return responses, fmt.Errorf("failed to create %d comments: %v", len(errors), errors)
+	if c.QuotedFileContent != nil {
+		comment.QuotedText = c.QuotedFileContent.Value
 	}
+	comment.IdempotencyKey, _ = idempotencyKeyOf(c.Content)
 
-	return responses, nil
+	for _, r := range c.Replies {
+		comment.Replies = append(comment.Replies, newReply(r))
+	}
+
+	return comment
 }
 
-// ListComments lists all comments on a Google Doc
-func (cm *CommentManager) ListComments(ctx context.Context, fileID string) ([]*drive.Comment, error) {
-	commentList, err := cm.driveService.Comments.
-		List(fileID).
+// newReply converts a Drive reply into a Reply
+func newReply(r *drive.Reply) Reply {
+	return Reply{
+		ID:         r.Id,
+		Author:     newAuthor(r.Author),
+		Content:    r.Content,
+		Action:     r.Action,
+		CreatedAt:  r.CreatedTime,
+		ModifiedAt: r.ModifiedTime,
+		Deleted:    r.Deleted,
+	}
+}
+
+// newAuthor converts a Drive user into an Author
+func newAuthor(user *drive.User) Author {
+	if user == nil {
+		return Author{}
+	}
+	return Author{
+		DisplayName:  user.DisplayName,
+		EmailAddress: user.EmailAddress,
+		Me:           user.Me,
+	}
+}
+
+// UpdateComment replaces the content of an existing comment.
+// A comment this tool posted stays signed and keeps its named range note and idempotency marker, so
+// the finding can still be found by its range name and key; a handwritten comment is not signed.
+// Content copied from ListComments is not signed twice.
+func (cm *CommentManager) UpdateComment(ctx context.Context, fileID, commentID, content string) (*Comment, error) {
+	content = stripSignature(content)
+	if content == "" {
+		return nil, fmt.Errorf("comment content must not be empty")
+	}
+
+	existing, err := cm.driveService.Comments.
+		Get(fileID, commentID).
 		Context(ctx).
-		Fields("comments(id,content,createdTime,anchor,quotedFileContent,author)").
+		Fields("content").
+		Do()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get comment: %w", err)
+	}
+	// Only comments this tool posted are signed again; a handwritten comment stays unsigned
+	body := content
+	if hasSignature(existing.Content) {
+		key, _ := idempotencyKeyOf(existing.Content)
+		body = commentBody(content, namedRangeOf(existing.Content), key)
+	}
+
+	updatedComment, err := cm.driveService.Comments.
+		Update(fileID, commentID, &drive.Comment{Content: body}).
+		Context(ctx).
+		Fields(commentFields).
 		Do()
 
 	if err != nil {
-		return nil, fmt.Errorf("failed to list comments: %w", err)
+		return nil, fmt.Errorf("failed to update comment: %w", err)
 	}
 
-	return commentList.Comments, nil
+	return newComment(updatedComment), nil
 }
 
 // DeleteComment deletes a comment from a Google Doc
@@ -203,23 +472,53 @@ func (cm *CommentManager) DeleteComment(ctx context.Context, fileID, commentID s
 	return nil
 }
 
-// createAnchorJSON creates the anchor JSON string for Drive API
-// Deprecated: Use createAnchorJSONWithPosition instead
-func createAnchorJSON(lineNumber int) (string, error) {
-	anchor := map[string]any{
-		"region": map[string]any{
-			"kind": "drive#commentRegion",
-			"line": lineNumber,
-			"rev":  "head", // Always use "head" for the latest revision
-		},
+// Reply actions supported by the Drive Replies API
+const (
+	ReplyActionResolve = "resolve"
+	ReplyActionReopen  = "reopen"
+)
+
+// ReplyToComment posts a reply to an existing comment thread
+func (cm *CommentManager) ReplyToComment(ctx context.Context, fileID, commentID, content string) (*Reply, error) {
+	if content == "" {
+		return nil, fmt.Errorf("reply content must not be empty")
 	}
 
-	anchorJSON, err := json.Marshal(anchor)
+	return cm.createReply(ctx, fileID, commentID, content, "")
+}
+
+// ResolveComment resolves a comment thread, optionally leaving a closing message
+func (cm *CommentManager) ResolveComment(ctx context.Context, fileID, commentID, content string) (*Reply, error) {
+	return cm.createReply(ctx, fileID, commentID, content, ReplyActionResolve)
+}
+
+// ReopenComment reopens a resolved comment thread, optionally explaining why
+func (cm *CommentManager) ReopenComment(ctx context.Context, fileID, commentID, content string) (*Reply, error) {
+	return cm.createReply(ctx, fileID, commentID, content, ReplyActionReopen)
+}
+
+// createReply creates a reply with an optional action on a comment thread
+func (cm *CommentManager) createReply(ctx context.Context, fileID, commentID, content, action string) (*Reply, error) {
+	reply := &drive.Reply{
+		Content: content,
+		Action:  action,
+	}
+
+	createdReply, err := cm.driveService.Replies.
+		Create(fileID, commentID, reply).
+		Context(ctx).
+		Fields("id,content,createdTime,modifiedTime,author,action").
+		Do()
+
 	if err != nil {
-		return "", fmt.Errorf("failed to marshal anchor: %w", err)
+		if action != "" {
+			return nil, fmt.Errorf("failed to %s comment: %w", action, err)
+		}
+		return nil, fmt.Errorf("failed to reply to comment: %w", err)
 	}
 
-	return string(anchorJSON), nil
+	r := newReply(createdReply)
+	return &r, nil
 }
 
 // createAnchorJSONWithPosition creates anchor JSON using text position
@@ -261,34 +560,94 @@ const (
 
 // Issue represents a problem found in a document
 type Issue struct {
-	Type        IssueType
-	Severity    IssueSeverity
-	LineNumber  int
-	TextContent string
-	Suggestion  string
-	Description string
+	Type          IssueType     `json:"type"`
+	Severity      IssueSeverity `json:"severity"`
+	LineNumber    int           `json:"line_number,omitempty"`
+	TextContent   string        `json:"quoted_text,omitempty"`
+	Occurrence    int           `json:"occurrence,omitempty"`     // Which occurrence of TextContent is meant
+	ContextBefore string        `json:"context_before,omitempty"` // Text immediately before TextContent
+	ContextAfter  string        `json:"context_after,omitempty"`  // Text immediately after TextContent
+	Suggestion    string        `json:"suggestion,omitempty"`
+	Replacement   string        `json:"replacement,omitempty"` // Exact text to replace TextContent with, for one-step fixes
+	Description   string        `json:"description"`
 }
 
-// CreateCommentsFromIssues converts a list of issues into comments
-func (cm *CommentManager) CreateCommentsFromIssues(ctx context.Context, fileID string, issues []Issue) ([]*CommentResponse, error) {
-	requests := make([]*CommentRequest, 0, len(issues))
+// Edit returns the edit that fixes the issue, if it proposes an exact replacement
+func (i Issue) Edit() (Edit, bool) {
+	if i.TextContent == "" || i.Replacement == "" {
+		return Edit{}, false
+	}
+
+	return Edit{
+		QuotedText:    i.TextContent,
+		Replacement:   i.Replacement,
+		Occurrence:    i.Occurrence,
+		ContextBefore: i.ContextBefore,
+		ContextAfter:  i.ContextAfter,
+	}, true
+}
+
+// Validate checks that the issue has a known type and severity and a description
+func (i Issue) Validate() error {
+	switch i.Type {
+	case IssueTypeGrammar, IssueTypeClarity, IssueTypeStructure, IssueTypeMissing, IssueTypeInconsistent:
+	default:
+		return fmt.Errorf("unknown issue type: %q", i.Type)
+	}
+
+	switch i.Severity {
+	case SeverityCritical, SeverityWarning, SeverityInfo:
+	default:
+		return fmt.Errorf("unknown issue severity: %q", i.Severity)
+	}
+
+	if i.Description == "" {
+		return fmt.Errorf("issue description must not be empty")
+	}
+
+	return nil
+}
 
-	for _, issue := range issues {
-		// Format comment content
-		content := formatIssueComment(issue)
+// CreateCommentsFromIssues converts a list of issues into comments.
+// All issues are validated before any comment is posted.
+func (cm *CommentManager) CreateCommentsFromIssues(ctx context.Context, fileID string, issues []Issue, opts *BatchOptions) (*BatchResult, error) {
+	requests := make([]*CommentRequest, 0, len(issues))
 
-		req := &CommentRequest{
-			FileID:     fileID,
-			Content:    content,
-			QuotedText: issue.TextContent,
-			LineNumber: issue.LineNumber,
-			LineLength: 1, // Default length
+	for i, issue := range issues {
+		if err := issue.Validate(); err != nil {
+			return nil, fmt.Errorf("issue %d: %w", i, err)
 		}
+		requests = append(requests, newIssueCommentRequest(fileID, issue))
+	}
 
-		requests = append(requests, req)
+	return cm.CreateMultipleComments(ctx, requests, opts)
+}
+
+// CreateCommentFromIssue converts a single issue into a comment. With an idempotency key the post
+// is retried safely, as described for CommentRequest.IdempotencyKey; an empty key posts once.
+func (cm *CommentManager) CreateCommentFromIssue(ctx context.Context, fileID string, issue Issue, idempotencyKey string) (*CommentResponse, error) {
+	if err := issue.Validate(); err != nil {
+		return nil, err
 	}
 
-	return cm.CreateMultipleComments(ctx, requests)
+	req := newIssueCommentRequest(fileID, issue)
+	req.IdempotencyKey = idempotencyKey
+	return cm.createComment(ctx, req)
+}
+
+// newIssueCommentRequest builds the comment request for an issue
+func newIssueCommentRequest(fileID string, issue Issue) *CommentRequest {
+	return &CommentRequest{
+		FileID:     fileID,
+		Content:    formatIssueComment(issue),
+		QuotedText: issue.TextContent,
+		LineNumber: issue.LineNumber,
+		LineLength: 1, // Default length
+
+		Occurrence:    issue.Occurrence,
+		ContextBefore: issue.ContextBefore,
+		ContextAfter:  issue.ContextAfter,
+	}
 }
 
 // formatIssueComment formats an issue into a readable comment
@@ -309,38 +668,47 @@ func formatIssueComment(issue Issue) string {
 
 // TextPosition represents a text location in a document
 type TextPosition struct {
-	StartIndex int64
-	EndIndex   int64
-	SegmentID  string
+	StartIndex  int64
+	EndIndex    int64
+	SegmentID   string
+	MatchedText string  // Text at the position, which may differ from the searched text for fuzzy matches
+	Confidence  float64 // Similarity between the searched and matched text, 1 for an exact match
 }
 
-// FindTextPosition searches for text in a document and returns its position
+// FindTextPosition searches for text in a document and returns its position.
+// The search covers the body, tables, headers, footers and footnotes, and matches text that spans
+// formatting runs. Indexes are Docs indexes in UTF-16 code units.
+// An *AmbiguousTextError is returned when the text occurs more than once; use LocateText to pick one.
 func (cm *CommentManager) FindTextPosition(ctx context.Context, fileID, searchText string) (*TextPosition, error) {
-	// Get the document content
-	doc, err := cm.docsService.Documents.Get(fileID).Do()
+	return cm.LocateText(ctx, fileID, &TextQuery{Text: searchText})
+}
+
+// LocateText finds the occurrence of a text selected by the query
+func (cm *CommentManager) LocateText(ctx context.Context, fileID string, query *TextQuery) (*TextPosition, error) {
+	// Get the document content, shared by the comments of a batch while its revision is unchanged
+	doc, err := cm.documents.GetCurrent(ctx, fileID, review.SuggestionsViewDefault)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get document: %w", err)
-	}
-
-	// Search through document content
-	for _, element := range doc.Body.Content {
-		if paragraph := element.Paragraph; paragraph != nil {
-			for _, paragraphElement := range paragraph.Elements {
-				if textRun := paragraphElement.TextRun; textRun != nil {
-					// Check if this text contains our search text
-					if strings.Contains(textRun.Content, searchText) {
-						// Calculate the exact position of the search text
-						offset := int64(strings.Index(textRun.Content, searchText))
-						return &TextPosition{
-							StartIndex: paragraphElement.StartIndex + offset,
-							EndIndex:   paragraphElement.StartIndex + offset + int64(len(searchText)),
-							SegmentID:  "",
-						}, nil
-					}
-				}
-			}
-		}
+		return nil, err
+	}
+
+	return locateText(doc, query)
+}
+
+// DocumentRevision returns the current revision ID of a document
+func (cm *CommentManager) DocumentRevision(ctx context.Context, fileID string) (string, error) {
+	doc, err := cm.docsService.Documents.Get(fileID).Context(ctx).Fields("revisionId").Do()
+	if err != nil {
+		return "", fmt.Errorf("failed to get document revision: %w", err)
+	}
+	return doc.RevisionId, nil
+}
+
+// ResolveLine returns the line with the given 1-based number, using review.BuildLines
+func (cm *CommentManager) ResolveLine(ctx context.Context, fileID string, lineNumber int) (review.Line, error) {
+	doc, err := cm.documents.GetCurrent(ctx, fileID, review.SuggestionsViewDefault)
+	if err != nil {
+		return review.Line{}, err
 	}
 
-	return nil, fmt.Errorf("text not found: %s", searchText)
+	return review.LineAt(review.BuildLines(doc), lineNumber)
 }
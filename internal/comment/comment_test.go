@@ -1,11 +1,18 @@
 package comment
 
 import (
+	"context"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"strings"
 	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/api/docs/v1"
+	"google.golang.org/api/drive/v3"
+	"google.golang.org/api/option"
 )
 
 func TestNewCommentManager(t *testing.T) {
@@ -29,75 +36,6 @@ func TestNewCommentManager(t *testing.T) {
 	}
 }
 
-func TestCreateAnchorJSON(t *testing.T) {
-	tests := []struct {
-		name       string
-		lineNumber int
-		wantErr    bool
-	}{
-		{
-			name:       "valid anchor line 10",
-			lineNumber: 10,
-			wantErr:    false,
-		},
-		{
-			name:       "valid anchor line 20",
-			lineNumber: 20,
-			wantErr:    false,
-		},
-		{
-			name:       "line number 1",
-			lineNumber: 1,
-			wantErr:    false,
-		},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			anchorJSON, err := createAnchorJSON(tt.lineNumber)
-
-			if (err != nil) != tt.wantErr {
-				t.Errorf("createAnchorJSON() error = %v, wantErr %v", err, tt.wantErr)
-				return
-			}
-
-			if err != nil {
-				return
-			}
-
-			// Verify JSON structure
-			var anchor map[string]interface{}
-			if err := json.Unmarshal([]byte(anchorJSON), &anchor); err != nil {
-				t.Errorf("createAnchorJSON() produced invalid JSON: %v", err)
-				return
-			}
-
-			// Check region structure
-			region, ok := anchor["region"].(map[string]interface{})
-			if !ok {
-				t.Error("createAnchorJSON() region object invalid")
-				return
-			}
-
-			// Verify kind
-			if region["kind"] != "drive#commentRegion" {
-				t.Errorf("createAnchorJSON() kind = %v, want 'drive#commentRegion'", region["kind"])
-			}
-
-			// Verify line number
-			line, ok := region["line"].(float64)
-			if !ok || int(line) != tt.lineNumber {
-				t.Errorf("createAnchorJSON() line number = %v, want %v", line, tt.lineNumber)
-			}
-
-			// Verify revision
-			if region["rev"] != "head" {
-				t.Errorf("createAnchorJSON() revision = %v, want 'head'", region["rev"])
-			}
-		})
-	}
-}
-
 func TestFormatIssueComment(t *testing.T) {
 	tests := []struct {
 		name  string
@@ -267,3 +205,602 @@ func TestCommentResponse(t *testing.T) {
 		t.Error("Content should not be empty")
 	}
 }
+
+// newTestCommentManager creates a CommentManager whose Drive and Docs services talk to a test server
+func newTestCommentManager(t *testing.T, handler http.Handler) *CommentManager {
+	t.Helper()
+
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	ctx := context.Background()
+	driveService, err := drive.NewService(ctx,
+		option.WithHTTPClient(server.Client()),
+		option.WithEndpoint(server.URL+"/drive/v3/"),
+	)
+	if err != nil {
+		t.Fatalf("failed to create Drive service: %v", err)
+	}
+
+	docsService, err := docs.NewService(ctx,
+		option.WithHTTPClient(server.Client()),
+		option.WithEndpoint(server.URL+"/"),
+	)
+	if err != nil {
+		t.Fatalf("failed to create Docs service: %v", err)
+	}
+
+	cm, err := NewCommentManagerWithServices(server.Client(), driveService, docsService)
+	if err != nil {
+		t.Fatalf("failed to create CommentManager: %v", err)
+	}
+
+	return cm
+}
+
+func TestListComments_FollowsAllPages(t *testing.T) {
+	var gotQueries []url.Values
+	cm := newTestCommentManager(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet || r.URL.Path != "/drive/v3/files/test-file-id/comments" {
+			http.NotFound(w, r)
+			return
+		}
+		gotQueries = append(gotQueries, r.URL.Query())
+
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Query().Get("pageToken") {
+		case "":
+			json.NewEncoder(w).Encode(map[string]any{
+				"nextPageToken": "page-2",
+				"comments": []map[string]any{
+					{
+						"id":                "c1",
+						"content":           "first",
+						"createdTime":       "2024-01-01T00:00:00Z",
+						"author":            map[string]any{"displayName": "Alice", "me": true},
+						"quotedFileContent": map[string]any{"value": "quoted"},
+						"replies": []map[string]any{
+							{"id": "r1", "content": "done", "action": "resolve", "author": map[string]any{"displayName": "Bob"}},
+						},
+						"resolved": true,
+					},
+				},
+			})
+		case "page-2":
+			json.NewEncoder(w).Encode(map[string]any{
+				"comments": []map[string]any{
+					{"id": "c2", "content": "second"},
+				},
+			})
+		default:
+			t.Errorf("unexpected pageToken %q", r.URL.Query().Get("pageToken"))
+		}
+	}))
+
+	got, err := cm.ListComments(context.Background(), "test-file-id", &ListCommentsOptions{
+		PageSize:          50,
+		IncludeDeleted:    true,
+		StartModifiedTime: "2024-01-01T00:00:00Z",
+	})
+	if err != nil {
+		t.Fatalf("ListComments() error = %v", err)
+	}
+
+	want := []*Comment{
+		{
+			ID:         "c1",
+			Author:     Author{DisplayName: "Alice", Me: true},
+			Content:    "first",
+			QuotedText: "quoted",
+			CreatedAt:  "2024-01-01T00:00:00Z",
+			Resolved:   true,
+			Replies: []Reply{
+				{ID: "r1", Author: Author{DisplayName: "Bob"}, Content: "done", Action: "resolve"},
+			},
+		},
+		{
+			ID:      "c2",
+			Content: "second",
+			Replies: []Reply{},
+		},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("ListComments() mismatch (-want +got):\n%s", diff)
+	}
+
+	if len(gotQueries) != 2 {
+		t.Fatalf("ListComments() made %d requests, want 2", len(gotQueries))
+	}
+	for _, q := range gotQueries {
+		if q.Get("pageSize") != "50" {
+			t.Errorf("pageSize = %q, want %q", q.Get("pageSize"), "50")
+		}
+		if q.Get("includeDeleted") != "true" {
+			t.Errorf("includeDeleted = %q, want %q", q.Get("includeDeleted"), "true")
+		}
+		if q.Get("startModifiedTime") != "2024-01-01T00:00:00Z" {
+			t.Errorf("startModifiedTime = %q, want %q", q.Get("startModifiedTime"), "2024-01-01T00:00:00Z")
+		}
+	}
+}
+
+func TestCreateReply_Actions(t *testing.T) {
+	tests := []struct {
+		name       string
+		call       func(cm *CommentManager) (*Reply, error)
+		wantAction string
+		wantErr    bool
+	}{
+		{
+			name: "reply",
+			call: func(cm *CommentManager) (*Reply, error) {
+				return cm.ReplyToComment(context.Background(), "test-file-id", "c1", "thanks")
+			},
+			wantAction: "",
+		},
+		{
+			name: "reply with empty content",
+			call: func(cm *CommentManager) (*Reply, error) {
+				return cm.ReplyToComment(context.Background(), "test-file-id", "c1", "")
+			},
+			wantErr: true,
+		},
+		{
+			name: "resolve",
+			call: func(cm *CommentManager) (*Reply, error) {
+				return cm.ResolveComment(context.Background(), "test-file-id", "c1", "fixed")
+			},
+			wantAction: ReplyActionResolve,
+		},
+		{
+			name: "reopen",
+			call: func(cm *CommentManager) (*Reply, error) {
+				return cm.ReopenComment(context.Background(), "test-file-id", "c1", "")
+			},
+			wantAction: ReplyActionReopen,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cm := newTestCommentManager(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Method != http.MethodPost || r.URL.Path != "/drive/v3/files/test-file-id/comments/c1/replies" {
+					http.NotFound(w, r)
+					return
+				}
+
+				var body map[string]any
+				if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+					t.Fatalf("failed to decode request body: %v", err)
+				}
+				action, _ := body["action"].(string)
+				if action != tt.wantAction {
+					t.Errorf("action = %q, want %q", action, tt.wantAction)
+				}
+
+				w.Header().Set("Content-Type", "application/json")
+				json.NewEncoder(w).Encode(map[string]any{
+					"id":      "r1",
+					"content": body["content"],
+					"action":  action,
+				})
+			}))
+
+			got, err := tt.call(cm)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+
+			if got.ID != "r1" || got.Action != tt.wantAction {
+				t.Errorf("got reply %+v, want ID r1 with action %q", got, tt.wantAction)
+			}
+		})
+	}
+}
+
+func TestUpdateComment(t *testing.T) {
+	existing := commentBody("finding", "gdr-finding-1", "key-1")
+	cm := newTestCommentManager(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/drive/v3/files/test-file-id/comments/c1" {
+			http.NotFound(w, r)
+			return
+		}
+		if r.Method == http.MethodGet {
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(map[string]any{"id": "c1", "content": existing})
+			return
+		}
+		if r.Method != http.MethodPatch {
+			http.NotFound(w, r)
+			return
+		}
+
+		var body map[string]any
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("failed to decode request body: %v", err)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]any{
+			"id":           "c1",
+			"content":      body["content"],
+			"modifiedTime": "2024-01-02T00:00:00Z",
+		})
+	}))
+
+	got, err := cm.UpdateComment(context.Background(), "test-file-id", "c1", "sharper finding")
+	if err != nil {
+		t.Fatalf("UpdateComment() error = %v", err)
+	}
+
+	if want := commentBody("sharper finding", "gdr-finding-1", "key-1"); got.Content != want {
+		t.Errorf("UpdateComment() content = %q, want %q", got.Content, want)
+	}
+	if got.ModifiedAt != "2024-01-02T00:00:00Z" {
+		t.Errorf("UpdateComment() modified at = %q, want %q", got.ModifiedAt, "2024-01-02T00:00:00Z")
+	}
+
+	// Content copied from a listed comment keeps a single signature
+	got, err = cm.UpdateComment(context.Background(), "test-file-id", "c1", signContent("sharper finding"))
+	if err != nil {
+		t.Fatalf("UpdateComment() error = %v", err)
+	}
+	if want := commentBody("sharper finding", "gdr-finding-1", "key-1"); got.Content != want {
+		t.Errorf("UpdateComment() with signed content = %q, want %q", got.Content, want)
+	}
+
+	// A handwritten comment is updated without the tool's signature
+	existing = "my own note"
+	got, err = cm.UpdateComment(context.Background(), "test-file-id", "c1", "my revised note")
+	if err != nil {
+		t.Fatalf("UpdateComment() error = %v", err)
+	}
+	if got.Content != "my revised note" {
+		t.Errorf("UpdateComment() on handwritten comment = %q, want %q", got.Content, "my revised note")
+	}
+
+	if _, err := cm.UpdateComment(context.Background(), "test-file-id", "c1", ""); err == nil {
+		t.Error("UpdateComment() with empty content should return error")
+	}
+}
+
+func TestIssue_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		issue   Issue
+		wantErr bool
+	}{
+		{
+			name:  "valid issue",
+			issue: Issue{Type: IssueTypeGrammar, Severity: SeverityCritical, Description: "typo"},
+		},
+		{
+			name:    "unknown type",
+			issue:   Issue{Type: "style", Severity: SeverityInfo, Description: "typo"},
+			wantErr: true,
+		},
+		{
+			name:    "unknown severity",
+			issue:   Issue{Type: IssueTypeGrammar, Severity: "blocker", Description: "typo"},
+			wantErr: true,
+		},
+		{
+			name:    "missing description",
+			issue:   Issue{Type: IssueTypeGrammar, Severity: SeverityInfo},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.issue.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestIssue_UnmarshalJSON(t *testing.T) {
+	input := `{"type":"grammar","severity":"critical","quoted_text":"テストデザインドッグ","description":"誤字","suggestion":"ドキュメント"}`
+
+	var got Issue
+	if err := json.Unmarshal([]byte(input), &got); err != nil {
+		t.Fatalf("json.Unmarshal() error = %v", err)
+	}
+
+	want := Issue{
+		Type:        IssueTypeGrammar,
+		Severity:    SeverityCritical,
+		TextContent: "テストデザインドッグ",
+		Description: "誤字",
+		Suggestion:  "ドキュメント",
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Issue mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestCreateAnchoredComment_ResolvesLine(t *testing.T) {
+	var posted map[string]any
+	cm := newTestCommentManager(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/v1/documents/test-file-id":
+			json.NewEncoder(w).Encode(map[string]any{
+				"body": map[string]any{
+					"content": []map[string]any{
+						{
+							"startIndex": 1,
+							"endIndex":   7,
+							"paragraph": map[string]any{
+								"elements": []map[string]any{
+									{"startIndex": 1, "endIndex": 7, "textRun": map[string]any{"content": "title\n"}},
+								},
+							},
+						},
+					},
+				},
+			})
+		case r.Method == http.MethodPost && r.URL.Path == "/drive/v3/files/test-file-id/comments":
+			json.NewDecoder(r.Body).Decode(&posted)
+			json.NewEncoder(w).Encode(map[string]any{"id": "comment-123"})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+
+	resp, err := cm.CreateAnchoredComment(context.Background(), &CommentRequest{
+		FileID:     "test-file-id",
+		Content:    "Fix this",
+		LineNumber: 1,
+	})
+	if err != nil {
+		t.Fatalf("CreateAnchoredComment() error = %v", err)
+	}
+	if resp.CommentID != "comment-123" {
+		t.Errorf("CreateAnchoredComment() comment ID = %q, want %q", resp.CommentID, "comment-123")
+	}
+
+	quoted, _ := posted["quotedFileContent"].(map[string]any)
+	if quoted["value"] != "title" {
+		t.Errorf("quoted text = %v, want line text %q", quoted["value"], "title")
+	}
+	if want := `{"region":{"endIndex":6,"startIndex":1}}`; posted["anchor"] != want {
+		t.Errorf("posted anchor = %v, want index range of the line %s", posted["anchor"], want)
+	}
+
+	_, err = cm.CreateAnchoredComment(context.Background(), &CommentRequest{
+		FileID:     "test-file-id",
+		Content:    "Fix this",
+		LineNumber: 2,
+	})
+	if err == nil {
+		t.Error("CreateAnchoredComment() with out of range line should return error")
+	}
+}
+
+func TestCreateComment_AnchorStatus(t *testing.T) {
+	tests := []struct {
+		name       string
+		quotedText string
+		strict     bool
+		docStatus  int
+		wantStatus AnchorStatus
+		wantPosted bool
+		wantErr    bool
+	}{
+		{
+			name:       "anchored",
+			quotedText: "title",
+			docStatus:  http.StatusOK,
+			wantStatus: AnchorStatusAnchored,
+			wantPosted: true,
+		},
+		{
+			name:       "no quoted text",
+			docStatus:  http.StatusOK,
+			wantStatus: AnchorStatusNone,
+			wantPosted: true,
+		},
+		{
+			name:       "quote not found",
+			quotedText: "missing paragraph",
+			docStatus:  http.StatusOK,
+			wantStatus: AnchorStatusQuotedOnly,
+			wantPosted: true,
+		},
+		{
+			name:       "quote only in header",
+			quotedText: "Confidential",
+			docStatus:  http.StatusOK,
+			wantStatus: AnchorStatusQuotedOnly,
+			wantPosted: true,
+		},
+		{
+			name:       "document fetch fails",
+			quotedText: "title",
+			docStatus:  http.StatusForbidden,
+			wantStatus: AnchorStatusFailed,
+			wantPosted: true,
+		},
+		{
+			name:       "strict mode refuses to post",
+			quotedText: "missing paragraph",
+			strict:     true,
+			docStatus:  http.StatusOK,
+			wantErr:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			posted := false
+			cm := newTestCommentManager(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				switch {
+				case r.Method == http.MethodGet && r.URL.Path == "/v1/documents/test-file-id":
+					w.WriteHeader(tt.docStatus)
+					json.NewEncoder(w).Encode(map[string]any{
+						"body": map[string]any{
+							"content": []map[string]any{
+								{
+									"startIndex": 1,
+									"endIndex":   7,
+									"paragraph": map[string]any{
+										"elements": []map[string]any{
+											{"startIndex": 1, "endIndex": 7, "textRun": map[string]any{"content": "title\n"}},
+										},
+									},
+								},
+							},
+						},
+						"headers": map[string]any{
+							"kix.header": map[string]any{
+								"content": []map[string]any{
+									{
+										"startIndex": 0,
+										"endIndex":   13,
+										"paragraph": map[string]any{
+											"elements": []map[string]any{
+												{"startIndex": 0, "endIndex": 13, "textRun": map[string]any{"content": "Confidential\n"}},
+											},
+										},
+									},
+								},
+							},
+						},
+					})
+				case r.Method == http.MethodPost && r.URL.Path == "/drive/v3/files/test-file-id/comments":
+					posted = true
+					var body map[string]any
+					json.NewDecoder(r.Body).Decode(&body)
+					json.NewEncoder(w).Encode(map[string]any{"id": "comment-123", "anchor": body["anchor"]})
+				default:
+					http.NotFound(w, r)
+				}
+			}))
+
+			resp, err := cm.CreateComment(context.Background(), &CommentRequest{
+				FileID:     "test-file-id",
+				Content:    "comment",
+				QuotedText: tt.quotedText,
+				Strict:     tt.strict,
+			})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("CreateComment() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if posted != tt.wantPosted {
+				t.Errorf("comment posted = %v, want %v", posted, tt.wantPosted)
+			}
+			if err != nil {
+				return
+			}
+
+			if resp.AnchorStatus != tt.wantStatus {
+				t.Errorf("AnchorStatus = %q, want %q", resp.AnchorStatus, tt.wantStatus)
+			}
+			if (resp.AnchorReason == "") != (tt.wantStatus == AnchorStatusAnchored || tt.wantStatus == AnchorStatusNone) {
+				t.Errorf("AnchorReason = %q for status %q", resp.AnchorReason, resp.AnchorStatus)
+			}
+			if (resp.Anchor != "") != (tt.wantStatus == AnchorStatusAnchored) {
+				t.Errorf("Anchor = %q for status %q", resp.Anchor, resp.AnchorStatus)
+			}
+		})
+	}
+}
+
+// recordingStrategy is an AnchorStrategy that records the requests it resolves
+type recordingStrategy struct {
+	resolved []*CommentRequest
+}
+
+func (s *recordingStrategy) Name() string { return "recording" }
+
+func (s *recordingStrategy) Resolve(ctx context.Context, cm *CommentManager, req *CommentRequest) *AnchorResult {
+	s.resolved = append(s.resolved, req)
+	return &AnchorResult{Status: AnchorStatusAnchored, Anchor: `{"custom":true}`, QuotedText: req.QuotedText}
+}
+
+func TestAnchorStrategySelection(t *testing.T) {
+	client := &http.Client{}
+
+	if _, err := NewCommentManager(client, WithDefaultAnchorStrategy("unknown")); err == nil {
+		t.Error("NewCommentManager() with unknown default strategy should return error")
+	}
+
+	custom := &recordingStrategy{}
+	cm, err := NewCommentManager(client, WithAnchorStrategy(custom), WithDefaultAnchorStrategy("recording"))
+	if err != nil {
+		t.Fatalf("NewCommentManager() error = %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		strategy string
+		want     string
+		wantErr  bool
+	}{
+		{name: "manager default", strategy: "", want: "recording"},
+		{name: "per request", strategy: AnchorStrategyQuotedText, want: AnchorStrategyQuotedText},
+		{name: "unknown", strategy: "named_range_v2", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := cm.anchorStrategy(&CommentRequest{AnchorStrategy: tt.strategy})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("anchorStrategy() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && got.Name() != tt.want {
+				t.Errorf("anchorStrategy() = %q, want %q", got.Name(), tt.want)
+			}
+		})
+	}
+}
+
+func TestCreateComment_CustomStrategy(t *testing.T) {
+	var posted map[string]any
+	cm := newTestCommentManager(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/drive/v3/files/test-file-id/comments" {
+			http.NotFound(w, r)
+			return
+		}
+		json.NewDecoder(r.Body).Decode(&posted)
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]any{"id": "comment-123", "anchor": posted["anchor"]})
+	}))
+
+	custom := &recordingStrategy{}
+	WithAnchorStrategy(custom)(cm)
+
+	resp, err := cm.CreateComment(context.Background(), &CommentRequest{
+		FileID:         "test-file-id",
+		Content:        "comment",
+		QuotedText:     "quote",
+		AnchorStrategy: "recording",
+	})
+	if err != nil {
+		t.Fatalf("CreateComment() error = %v", err)
+	}
+
+	if len(custom.resolved) != 1 {
+		t.Errorf("strategy resolved %d requests, want 1", len(custom.resolved))
+	}
+	if posted["anchor"] != `{"custom":true}` {
+		t.Errorf("posted anchor = %v, want custom anchor", posted["anchor"])
+	}
+	if resp.AnchorStatus != AnchorStatusAnchored {
+		t.Errorf("AnchorStatus = %q, want %q", resp.AnchorStatus, AnchorStatusAnchored)
+	}
+}
+
+func TestQuotedTextAnchorStrategy(t *testing.T) {
+	got := QuotedTextAnchorStrategy{}.Resolve(context.Background(), nil, &CommentRequest{QuotedText: "quote"})
+
+	if got.Status != AnchorStatusQuotedOnly || got.Anchor != "" || got.QuotedText != "quote" {
+		t.Errorf("Resolve() = %+v, want quoted only result without anchor", got)
+	}
+}
@@ -0,0 +1,70 @@
+package comment
+
+import (
+	"context"
+)
+
+// DefaultDuplicateSimilarity is the content similarity above which a comment counts as a duplicate
+const DefaultDuplicateSimilarity = 0.8
+
+// duplicateSimilarity returns the similarity threshold for duplicates
+func (o *BatchOptions) duplicateSimilarity() float64 {
+	if o.DuplicateSimilarity > 0 {
+		return o.DuplicateSimilarity
+	}
+	return DefaultDuplicateSimilarity
+}
+
+// SkippedComment is a request that was not posted because an equivalent comment exists
+type SkippedComment struct {
+	Index   int // Index of the request in the batch
+	Request *CommentRequest
+	// Duplicate is the existing comment the request duplicates. When it duplicates an earlier
+	// request of the same batch that failed, its ID is empty; retrying the failed requests posts it.
+	Duplicate  *Comment
+	Similarity float64 // Similarity between the request and the existing content
+}
+
+// duplicateFinder finds existing comments equivalent to a request
+type duplicateFinder struct {
+	comments  *batchComments
+	threshold float64
+}
+
+// newDuplicateFinder creates a duplicateFinder with the similarity threshold of opts
+func newDuplicateFinder(comments *batchComments, opts *BatchOptions) *duplicateFinder {
+	return &duplicateFinder{
+		comments:  comments,
+		threshold: opts.duplicateSimilarity(),
+	}
+}
+
+// find returns the existing comment that req duplicates, if any
+func (f *duplicateFinder) find(ctx context.Context, req *CommentRequest) (*Comment, float64, error) {
+	comments, err := f.comments.list(ctx, req.FileID)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// Posted comments quote the text as matched in the document, which may differ from the
+	// requested quote by whitespace and surrounding punctuation
+	quotedText := normalizeQuery(req.QuotedText)
+	for _, c := range comments {
+		if c.Deleted || normalizeQuery(c.QuotedText) != quotedText {
+			continue
+		}
+		if score := similarity(req.Content, stripSignature(c.Content)); score >= f.threshold {
+			return c, score, nil
+		}
+	}
+
+	return nil, 0, nil
+}
+
+// add records a comment that will be posted in this batch, so later requests in the batch are
+// compared with it. The returned comment is filled in once the request has been posted.
+func (f *duplicateFinder) add(req *CommentRequest) *Comment {
+	c := &Comment{Content: req.Content, QuotedText: req.QuotedText}
+	f.comments.add(req.FileID, c)
+	return c
+}
@@ -0,0 +1,125 @@
+package comment
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestCreateMultipleComments_SkipDuplicates(t *testing.T) {
+	tests := []struct {
+		name        string
+		opts        *BatchOptions
+		wantPosted  []string
+		wantSkipped []int
+		wantLists   int
+	}{
+		{
+			name:       "duplicates posted without the option",
+			opts:       nil,
+			wantPosted: []string{"誤字があります。「ドキュメント」が正しいです。", "内容が不十分です。", "誤字があります。「ドキュメント」が正しいです!", "内容が不十分です。"},
+			wantLists:  0,
+		},
+		{
+			name:        "skip existing and in-batch duplicates",
+			opts:        &BatchOptions{SkipDuplicates: true},
+			wantPosted:  []string{"内容が不十分です。"},
+			wantSkipped: []int{0, 2, 3},
+			wantLists:   1,
+		},
+		{
+			name:        "strict similarity",
+			opts:        &BatchOptions{SkipDuplicates: true, DuplicateSimilarity: 1},
+			wantPosted:  []string{"内容が不十分です。", "誤字があります。「ドキュメント」が正しいです!"},
+			wantSkipped: []int{0, 3},
+			wantLists:   1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				mu     sync.Mutex
+				posted []string
+				lists  int
+			)
+			cm := newTestCommentManager(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				mu.Lock()
+				defer mu.Unlock()
+
+				w.Header().Set("Content-Type", "application/json")
+				switch {
+				case r.Method == http.MethodGet && r.URL.Path == "/drive/v3/files/test-file-id/comments":
+					lists++
+					json.NewEncoder(w).Encode(map[string]any{
+						"comments": []map[string]any{
+							{
+								"id":                "human-1",
+								"content":           "誤字があります。「ドキュメント」が正しいです。",
+								"author":            map[string]any{"displayName": "Alice"},
+								"quotedFileContent": map[string]any{"value": "ドッグ"},
+							},
+							{
+								"id":                "deleted-1",
+								"content":           "内容が不十分です。",
+								"quotedFileContent": map[string]any{"value": "テストテスト"},
+								"deleted":           true,
+							},
+						},
+					})
+				case r.Method == http.MethodPost && r.URL.Path == "/drive/v3/files/test-file-id/comments":
+					var body map[string]any
+					json.NewDecoder(r.Body).Decode(&body)
+					content, _ := body["content"].(string)
+					posted = append(posted, stripSignature(content))
+					json.NewEncoder(w).Encode(map[string]any{"id": fmt.Sprintf("comment-%d", len(posted)), "content": content})
+				default:
+					http.NotFound(w, r)
+				}
+			}))
+
+			requests := []*CommentRequest{
+				{FileID: "test-file-id", Content: "誤字があります。「ドキュメント」が正しいです。", QuotedText: "ドッグ", AnchorStrategy: AnchorStrategyQuotedText},
+				{FileID: "test-file-id", Content: "内容が不十分です。", QuotedText: "テストテスト", AnchorStrategy: AnchorStrategyQuotedText},
+				{FileID: "test-file-id", Content: "誤字があります。「ドキュメント」が正しいです!", QuotedText: " ドッグ。", AnchorStrategy: AnchorStrategyQuotedText},
+				{FileID: "test-file-id", Content: "内容が不十分です。", QuotedText: "テストテスト", AnchorStrategy: AnchorStrategyQuotedText},
+			}
+
+			result, err := cm.CreateMultipleComments(context.Background(), requests, tt.opts)
+			if err != nil {
+				t.Fatalf("CreateMultipleComments() error = %v", err)
+			}
+
+			// Comments are posted concurrently, so only the set of posted comments is compared
+			sort.Strings(tt.wantPosted)
+			sort.Strings(posted)
+			if diff := cmp.Diff(tt.wantPosted, posted); diff != "" {
+				t.Errorf("posted comments mismatch (-want +got):\n%s", diff)
+			}
+			if len(result.Responses) != len(tt.wantPosted) {
+				t.Errorf("got %d responses, want %d", len(result.Responses), len(tt.wantPosted))
+			}
+
+			var skipped []int
+			for _, s := range result.Skipped {
+				skipped = append(skipped, s.Index)
+				if s.Duplicate == nil || s.Duplicate.Deleted {
+					t.Errorf("skipped request %d has no live duplicate: %+v", s.Index, s.Duplicate)
+				}
+			}
+			if diff := cmp.Diff(tt.wantSkipped, skipped); diff != "" {
+				t.Errorf("skipped requests mismatch (-want +got):\n%s", diff)
+			}
+
+			if lists != tt.wantLists {
+				t.Errorf("listed comments %d times, want %d", lists, tt.wantLists)
+			}
+		})
+	}
+}
@@ -0,0 +1,148 @@
+package comment
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// MaxIdempotencyKeyLength is the maximum length of CommentRequest.IdempotencyKey in bytes
+const MaxIdempotencyKeyLength = 64
+
+// The idempotency key is embedded in the comment body as invisible characters: each bit of the key
+// is a zero-width space (0) or a zero-width non-joiner (1), framed by word joiners.
+const (
+	idempotencyMarkerFrame = "\u2060"
+	idempotencyMarkerZero  = '\u200b'
+	idempotencyMarkerOne   = '\u200c'
+)
+
+// recentCommentWindow is how far before the first attempt a retry looks for the comment it may have posted
+const recentCommentWindow = time.Minute
+
+// NewIdempotencyKey returns a new random idempotency key
+func NewIdempotencyKey() (string, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate idempotency key: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// validateIdempotencyKey checks that a key can be embedded in a comment
+func validateIdempotencyKey(key string) error {
+	if len(key) > MaxIdempotencyKeyLength {
+		return fmt.Errorf("idempotency key must be at most %d bytes", MaxIdempotencyKeyLength)
+	}
+	return nil
+}
+
+// idempotencyMarker returns the invisible marker encoding key, or "" for an empty key
+func idempotencyMarker(key string) string {
+	if key == "" {
+		return ""
+	}
+
+	var builder strings.Builder
+	builder.WriteString(idempotencyMarkerFrame)
+	for i := 0; i < len(key); i++ {
+		for bit := 7; bit >= 0; bit-- {
+			if key[i]&(1<<bit) != 0 {
+				builder.WriteRune(idempotencyMarkerOne)
+			} else {
+				builder.WriteRune(idempotencyMarkerZero)
+			}
+		}
+	}
+	builder.WriteString(idempotencyMarkerFrame)
+	return builder.String()
+}
+
+// findIdempotencyMarker returns the key of the last marker in content and the byte range of the marker
+func findIdempotencyMarker(content string) (key string, start, end int, ok bool) {
+	end = strings.LastIndex(content, idempotencyMarkerFrame)
+	if end < 0 {
+		return "", 0, 0, false
+	}
+	start = strings.LastIndex(content[:end], idempotencyMarkerFrame)
+	if start < 0 {
+		return "", 0, 0, false
+	}
+
+	bits := []rune(content[start+len(idempotencyMarkerFrame) : end])
+	if len(bits) == 0 || len(bits)%8 != 0 {
+		return "", 0, 0, false
+	}
+
+	decoded := make([]byte, len(bits)/8)
+	for i, r := range bits {
+		switch r {
+		case idempotencyMarkerOne:
+			decoded[i/8] |= 1 << (7 - i%8)
+		case idempotencyMarkerZero:
+		default:
+			return "", 0, 0, false
+		}
+	}
+
+	return string(decoded), start, end + len(idempotencyMarkerFrame), true
+}
+
+// idempotencyKeyOf returns the idempotency key embedded in a comment body, if any
+func idempotencyKeyOf(content string) (string, bool) {
+	key, _, _, ok := findIdempotencyMarker(content)
+	return key, ok
+}
+
+// removeIdempotencyMarker returns content without its idempotency marker
+func removeIdempotencyMarker(content string) string {
+	_, start, end, ok := findIdempotencyMarker(content)
+	if !ok {
+		return content
+	}
+	return content[:start] + content[end:]
+}
+
+// findPostedComment returns the live comment carrying the idempotency key, or nil if there is none.
+// Only comments modified since the given time are listed, a zero time lists every comment.
+func (cm *CommentManager) findPostedComment(ctx context.Context, fileID, key string, since time.Time) (*Comment, error) {
+	opts := &ListCommentsOptions{}
+	if !since.IsZero() {
+		opts.StartModifiedTime = since.UTC().Format(time.RFC3339)
+	}
+
+	comments, err := cm.ListComments(ctx, fileID, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return postedComment(comments, key), nil
+}
+
+// postedComment returns the live comment carrying the idempotency key, or nil if there is none
+func postedComment(comments []*Comment, key string) *Comment {
+	for _, c := range comments {
+		if c.Deleted {
+			continue
+		}
+		if commentKey, ok := idempotencyKeyOf(c.Content); ok && commentKey == key {
+			return c
+		}
+	}
+	return nil
+}
+
+// reusedResponse returns the response for a comment an earlier attempt already posted
+func reusedResponse(c *Comment) *CommentResponse {
+	return &CommentResponse{
+		CommentID:   c.ID,
+		Content:     c.Content,
+		Anchor:      c.Anchor,
+		CreatedAt:   c.CreatedAt,
+		MatchedText: c.QuotedText,
+		Reused:      true,
+	}
+}
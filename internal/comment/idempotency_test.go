@@ -0,0 +1,249 @@
+package comment
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/takeuchi-shogo/google-doc-review/internal/retry"
+)
+
+func TestIdempotencyMarker(t *testing.T) {
+	tests := []struct {
+		name    string
+		key     string
+		content string
+	}{
+		{name: "generated key", key: "0123456789abcdef", content: "typo"},
+		{name: "non ascii key", key: "レビュー-1", content: "typo"},
+		{name: "multi line content", key: "k", content: "typo\n\nsecond paragraph"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			marked := tt.content + idempotencyMarker(tt.key)
+
+			if got, ok := idempotencyKeyOf(marked); !ok || got != tt.key {
+				t.Errorf("idempotencyKeyOf() = %q, %v, want %q, true", got, ok, tt.key)
+			}
+			if got := removeIdempotencyMarker(marked); got != tt.content {
+				t.Errorf("removeIdempotencyMarker() = %q, want %q", got, tt.content)
+			}
+			if got := stripSignature(signContent(marked)); got != tt.content {
+				t.Errorf("stripSignature() = %q, want %q", got, tt.content)
+			}
+			if strings.Contains(marked[len(tt.content):], tt.key) {
+				t.Errorf("marker %q contains the key in plain text", marked[len(tt.content):])
+			}
+		})
+	}
+
+	if _, ok := idempotencyKeyOf("no marker"); ok {
+		t.Error("idempotencyKeyOf() found a key in content without a marker")
+	}
+	if idempotencyMarker("") != "" {
+		t.Error("idempotencyMarker() of an empty key should be empty")
+	}
+}
+
+// flakyCommentServer is a Drive comments endpoint whose first POSTs fail with 503
+type flakyCommentServer struct {
+	mu       sync.Mutex
+	failures int  // Number of POSTs answered with 503
+	accept   bool // Whether a failed POST still creates the comment
+	posts    int
+	lists    int
+	comments []map[string]any
+}
+
+func (s *flakyCommentServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	switch {
+	case r.Method == http.MethodGet && r.URL.Path == "/drive/v3/files/test-file-id/comments":
+		s.lists++
+		json.NewEncoder(w).Encode(map[string]any{"comments": s.comments})
+	case r.Method == http.MethodPost && r.URL.Path == "/drive/v3/files/test-file-id/comments":
+		s.posts++
+		var body map[string]any
+		json.NewDecoder(r.Body).Decode(&body)
+
+		failed := s.posts <= s.failures
+		if !failed || s.accept {
+			s.comments = append(s.comments, map[string]any{
+				"id":          fmt.Sprintf("comment-%d", len(s.comments)+1),
+				"content":     body["content"],
+				"createdTime": "2024-01-01T00:00:00Z",
+				"author":      map[string]any{"me": true},
+			})
+		}
+		if failed {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			json.NewEncoder(w).Encode(map[string]any{"error": map[string]any{"code": http.StatusServiceUnavailable, "message": "backend error"}})
+			return
+		}
+		json.NewEncoder(w).Encode(s.comments[len(s.comments)-1])
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func TestCreateComment_IdempotencyKey(t *testing.T) {
+	tests := []struct {
+		name         string
+		key          string
+		failures     int
+		accept       bool
+		wantErr      bool
+		wantPosts    int
+		wantLists    int
+		wantComments int
+		wantReused   bool
+	}{
+		{
+			name:         "accepted despite error is not posted again",
+			key:          "key-1",
+			failures:     1,
+			accept:       true,
+			wantPosts:    1,
+			wantLists:    1,
+			wantComments: 1,
+			wantReused:   true,
+		},
+		{
+			name:         "rejected post is retried",
+			key:          "key-1",
+			failures:     2,
+			wantPosts:    3,
+			wantLists:    2,
+			wantComments: 1,
+		},
+		{
+			name:         "without key the post is not retried",
+			failures:     1,
+			wantErr:      true,
+			wantPosts:    1,
+			wantComments: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := &flakyCommentServer{failures: tt.failures, accept: tt.accept}
+			cm := newTestCommentManager(t, server)
+			cm.retry = retry.Config{BaseDelay: time.Millisecond}
+
+			resp, err := cm.CreateComment(context.Background(), &CommentRequest{
+				FileID:         "test-file-id",
+				Content:        "typo",
+				AnchorStrategy: AnchorStrategyQuotedText,
+				IdempotencyKey: tt.key,
+			})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("CreateComment() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if server.posts != tt.wantPosts {
+				t.Errorf("posts = %d, want %d", server.posts, tt.wantPosts)
+			}
+			if server.lists != tt.wantLists {
+				t.Errorf("lists = %d, want %d", server.lists, tt.wantLists)
+			}
+			if len(server.comments) != tt.wantComments {
+				t.Errorf("comments = %d, want %d", len(server.comments), tt.wantComments)
+			}
+			if err != nil {
+				return
+			}
+
+			if resp.Reused != tt.wantReused {
+				t.Errorf("Reused = %v, want %v", resp.Reused, tt.wantReused)
+			}
+			if resp.CommentID != "comment-1" {
+				t.Errorf("CommentID = %q, want %q", resp.CommentID, "comment-1")
+			}
+			if key, ok := idempotencyKeyOf(resp.Content); !ok || key != tt.key {
+				t.Errorf("content key = %q, %v, want %q", key, ok, tt.key)
+			}
+		})
+	}
+}
+
+func TestCreateMultipleComments_IdempotencyKey(t *testing.T) {
+	server := &flakyCommentServer{failures: 1, accept: true}
+	cm := newTestCommentManager(t, server)
+	cm.retry = retry.Config{MaxAttempts: 1}
+
+	requests := []*CommentRequest{
+		{FileID: "test-file-id", Content: "first", AnchorStrategy: AnchorStrategyQuotedText},
+		{FileID: "test-file-id", Content: "second", AnchorStrategy: AnchorStrategyQuotedText},
+	}
+	opts := &BatchOptions{Concurrency: 1, RequestsPerSecond: -1}
+
+	// The first post fails although Drive accepted it
+	_, err := cm.CreateMultipleComments(context.Background(), requests, opts)
+	batchErr, ok := err.(*BatchError)
+	if !ok {
+		t.Fatalf("CreateMultipleComments() error = %v, want *BatchError", err)
+	}
+	for _, req := range requests {
+		if req.IdempotencyKey == "" {
+			t.Errorf("request %q has no idempotency key", req.Content)
+		}
+	}
+
+	// Passing the failed request again finds the accepted comment instead of posting it twice
+	result, err := cm.CreateMultipleComments(context.Background(), batchErr.Requests(), opts)
+	if err != nil {
+		t.Fatalf("CreateMultipleComments() retry error = %v", err)
+	}
+
+	if server.posts != 2 {
+		t.Errorf("posts = %d, want 2", server.posts)
+	}
+	var contents []string
+	for _, c := range server.comments {
+		contents = append(contents, stripSignature(c["content"].(string)))
+	}
+	if diff := cmp.Diff([]string{"first", "second"}, contents); diff != "" {
+		t.Errorf("comments mismatch (-want +got):\n%s", diff)
+	}
+	if len(result.Responses) != 1 || !result.Responses[0].Reused || result.Responses[0].CommentID != "comment-1" {
+		t.Errorf("retry responses = %+v, want the reused comment-1", result.Responses)
+	}
+}
+
+func TestCreateMultipleComments_ListsCommentsOnce(t *testing.T) {
+	server := &flakyCommentServer{comments: []map[string]any{
+		{"id": "comment-1", "content": commentBody("typo in the heading", "", "key-1"), "author": map[string]any{"me": true}},
+	}}
+	cm := newTestCommentManager(t, server)
+
+	// One request was posted by an earlier call, the other is new and checked for duplicates
+	requests := []*CommentRequest{
+		{FileID: "test-file-id", Content: "typo in the heading", AnchorStrategy: AnchorStrategyQuotedText, IdempotencyKey: "key-1"},
+		{FileID: "test-file-id", Content: "missing error handling section", AnchorStrategy: AnchorStrategyQuotedText},
+	}
+	result, err := cm.CreateMultipleComments(context.Background(), requests, &BatchOptions{SkipDuplicates: true, RequestsPerSecond: -1})
+	if err != nil {
+		t.Fatalf("CreateMultipleComments() error = %v", err)
+	}
+
+	if server.lists != 1 {
+		t.Errorf("comments listed %d times, want 1", server.lists)
+	}
+	if server.posts != 1 {
+		t.Errorf("posts = %d, want 1", server.posts)
+	}
+	if !result.Items[0].Response.Reused || result.Items[1].Response.Reused {
+		t.Errorf("reused = (%v, %v), want (true, false)", result.Items[0].Response.Reused, result.Items[1].Response.Reused)
+	}
+}
@@ -0,0 +1,208 @@
+package comment
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/takeuchi-shogo/google-doc-review/internal/review"
+	"google.golang.org/api/docs/v1"
+)
+
+// AnchorStrategyNamedRange is the name of NamedRangeAnchorStrategy
+const AnchorStrategyNamedRange = "named_range"
+
+// NamedRangePrefix is the prefix of the named ranges created for comments
+const NamedRangePrefix = "gdr-finding-"
+
+// NamedRangeAnchorStrategy locates the quoted text like PositionAnchorStrategy and additionally
+// creates a Docs named range around it. Drive anchors are not honored by Google Docs, but a named
+// range follows the text through later edits, so a finding can be found again by its range name.
+type NamedRangeAnchorStrategy struct{}
+
+func (NamedRangeAnchorStrategy) Name() string { return AnchorStrategyNamedRange }
+
+func (NamedRangeAnchorStrategy) Resolve(ctx context.Context, cm *CommentManager, req *CommentRequest) *AnchorResult {
+	result := PositionAnchorStrategy{}.Resolve(ctx, cm, req)
+	if result.Status != AnchorStatusAnchored {
+		return result
+	}
+
+	name, err := newNamedRangeName()
+	if err != nil {
+		result.Status = AnchorStatusFailed
+		result.Reason = err
+		return result
+	}
+
+	if _, err := cm.CreateNamedRange(ctx, req.FileID, name, result.Position); err != nil {
+		// Without the named range the comment is posted with the quoted text only
+		result.Status = AnchorStatusFailed
+		result.Reason = err
+		result.Anchor = ""
+		return result
+	}
+
+	result.NamedRange = name
+	return result
+}
+
+// newNamedRangeName returns a new random named range name
+func newNamedRangeName() (string, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate named range name: %w", err)
+	}
+	return NamedRangePrefix + hex.EncodeToString(b), nil
+}
+
+// namedRangeNotePrefix starts the line that records a named range in a comment body
+const namedRangeNotePrefix = "[named range: "
+
+// namedRangeNote returns the line appended to a comment body to record its named range
+func namedRangeNote(name string) string {
+	return namedRangeNotePrefix + name + "]"
+}
+
+// namedRangeOf returns the named range recorded in a comment body, or "" if there is none
+func namedRangeOf(content string) string {
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(removeIdempotencyMarker(line))
+		if strings.HasPrefix(line, namedRangeNotePrefix) && strings.HasSuffix(line, "]") {
+			return strings.TrimSuffix(strings.TrimPrefix(line, namedRangeNotePrefix), "]")
+		}
+	}
+	return ""
+}
+
+// CreateNamedRange creates a named range covering pos and returns its ID.
+// pos must have been located in the current revision of the document, e.g. by LocateText.
+func (cm *CommentManager) CreateNamedRange(ctx context.Context, fileID, name string, pos *TextPosition) (string, error) {
+	doc, err := cm.documents.GetCurrent(ctx, fileID, review.SuggestionsViewDefault)
+	if err != nil {
+		return "", err
+	}
+
+	req := &docs.BatchUpdateDocumentRequest{
+		Requests: []*docs.Request{
+			{
+				CreateNamedRange: &docs.CreateNamedRangeRequest{
+					Name: name,
+					Range: &docs.Range{
+						SegmentId:  pos.SegmentID,
+						StartIndex: pos.StartIndex,
+						EndIndex:   pos.EndIndex,
+					},
+				},
+			},
+		},
+	}
+	// Refused if the document was edited since pos was located, as the range would cover other text
+	base := doc.RevisionId
+	if base != "" {
+		req.WriteControl = &docs.WriteControl{RequiredRevisionId: base}
+	}
+
+	resp, err := cm.docsService.Documents.BatchUpdate(fileID, req).Context(ctx).Do()
+	if err != nil {
+		return "", fmt.Errorf("failed to create named range: %w", err)
+	}
+
+	if len(resp.Replies) == 0 || resp.Replies[0].CreateNamedRange == nil {
+		cm.documents.Invalidate(fileID)
+		return "", fmt.Errorf("failed to create named range: empty reply")
+	}
+
+	// A named range moves no text, so the cached document is updated instead of downloaded again.
+	// The write control guarantees the range is the only change since base, so a cached copy at
+	// base moves to the revision after it.
+	id := resp.Replies[0].CreateNamedRange.NamedRangeId
+	cm.documents.Revise(fileID, func(doc *docs.Document) {
+		doc.NamedRanges = withNamedRange(doc.NamedRanges, &docs.NamedRange{
+			Name:         name,
+			NamedRangeId: id,
+			Ranges:       []*docs.Range{req.Requests[0].CreateNamedRange.Range},
+		})
+		if base != "" && doc.RevisionId == base && resp.WriteControl != nil {
+			doc.RevisionId = resp.WriteControl.RequiredRevisionId
+		}
+	})
+
+	return id, nil
+}
+
+// withNamedRange returns a copy of the named ranges of a document with namedRange added
+func withNamedRange(namedRanges map[string]docs.NamedRanges, namedRange *docs.NamedRange) map[string]docs.NamedRanges {
+	revised := make(map[string]docs.NamedRanges, len(namedRanges)+1)
+	for name, ranges := range namedRanges {
+		revised[name] = ranges
+	}
+
+	ranges := revised[namedRange.Name]
+	ranges.Name = namedRange.Name
+	ranges.NamedRanges = append(append([]*docs.NamedRange(nil), ranges.NamedRanges...), namedRange)
+	revised[namedRange.Name] = ranges
+	return revised
+}
+
+// FindNamedRange returns the current positions of the ranges with the given name.
+// A range may have been split into several parts by edits, so every part is returned in order.
+func (cm *CommentManager) FindNamedRange(ctx context.Context, fileID, name string) ([]*TextPosition, error) {
+	doc, err := cm.documents.GetCurrent(ctx, fileID, review.SuggestionsViewDefault)
+	if err != nil {
+		return nil, err
+	}
+
+	return findNamedRange(doc, name)
+}
+
+// findNamedRange returns the positions and text of the ranges with the given name in a document
+func findNamedRange(doc *docs.Document, name string) ([]*TextPosition, error) {
+	named, ok := doc.NamedRanges[name]
+	if !ok || len(named.NamedRanges) == 0 {
+		return nil, fmt.Errorf("%w: named range %s", ErrTextNotFound, name)
+	}
+
+	segments := make(map[string]*segmentText)
+	for _, segment := range linearizeDocument(doc) {
+		segments[segment.SegmentID] = segment
+	}
+
+	positions := make([]*TextPosition, 0)
+	for _, namedRange := range named.NamedRanges {
+		for _, r := range namedRange.Ranges {
+			position := &TextPosition{
+				StartIndex: r.StartIndex,
+				EndIndex:   r.EndIndex,
+				SegmentID:  r.SegmentId,
+				Confidence: 1,
+			}
+			if segment, ok := segments[r.SegmentId]; ok {
+				position.MatchedText = segment.textBetween(r.StartIndex, r.EndIndex)
+			}
+			positions = append(positions, position)
+		}
+	}
+
+	return positions, nil
+}
+
+// textBetween returns the linearized text within the Docs index range [start, end)
+func (s *segmentText) textBetween(start, end int64) string {
+	text := s.String()
+
+	var builder strings.Builder
+	for i, index := range s.indexes {
+		if index < start || s.ends[i] > end {
+			continue
+		}
+		next := len(text)
+		if i+1 < len(s.offsets) {
+			next = s.offsets[i+1]
+		}
+		builder.WriteString(text[s.offsets[i]:next])
+	}
+	return builder.String()
+}
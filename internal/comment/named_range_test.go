@@ -0,0 +1,253 @@
+package comment
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/api/docs/v1"
+)
+
+func TestCreateComment_NamedRange(t *testing.T) {
+	var (
+		rangeRequest *docs.CreateNamedRangeRequest
+		postedBody   map[string]any
+	)
+	cm := newTestCommentManager(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/v1/documents/test-file-id":
+			json.NewEncoder(w).Encode(&docs.Document{
+				Body: &docs.Body{Content: []*docs.StructuralElement{textParagraph(1, "intro title\n")}},
+			})
+		case r.Method == http.MethodPost && r.URL.Path == "/v1/documents/test-file-id:batchUpdate":
+			var req docs.BatchUpdateDocumentRequest
+			json.NewDecoder(r.Body).Decode(&req)
+			if len(req.Requests) == 1 {
+				rangeRequest = req.Requests[0].CreateNamedRange
+			}
+			json.NewEncoder(w).Encode(&docs.BatchUpdateDocumentResponse{
+				Replies: []*docs.Response{{CreateNamedRange: &docs.CreateNamedRangeResponse{NamedRangeId: "kix.range"}}},
+			})
+		case r.Method == http.MethodPost && r.URL.Path == "/drive/v3/files/test-file-id/comments":
+			json.NewDecoder(r.Body).Decode(&postedBody)
+			json.NewEncoder(w).Encode(map[string]any{"id": "comment-123", "content": postedBody["content"]})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+
+	resp, err := cm.CreateComment(context.Background(), &CommentRequest{
+		FileID:         "test-file-id",
+		Content:        "comment",
+		QuotedText:     "title",
+		AnchorStrategy: AnchorStrategyNamedRange,
+	})
+	if err != nil {
+		t.Fatalf("CreateComment() error = %v", err)
+	}
+
+	if resp.AnchorStatus != AnchorStatusAnchored {
+		t.Errorf("AnchorStatus = %q, want %q (reason: %s)", resp.AnchorStatus, AnchorStatusAnchored, resp.AnchorReason)
+	}
+	if !strings.HasPrefix(resp.NamedRange, NamedRangePrefix) {
+		t.Errorf("NamedRange = %q, want prefix %q", resp.NamedRange, NamedRangePrefix)
+	}
+
+	wantRange := &docs.CreateNamedRangeRequest{
+		Name:  resp.NamedRange,
+		Range: &docs.Range{StartIndex: 7, EndIndex: 12},
+	}
+	if diff := cmp.Diff(wantRange, rangeRequest); diff != "" {
+		t.Errorf("createNamedRange request mismatch (-want +got):\n%s", diff)
+	}
+
+	wantContent := signContent("comment\n\n" + namedRangeNote(resp.NamedRange))
+	if postedBody["content"] != wantContent {
+		t.Errorf("posted content = %q, want %q", postedBody["content"], wantContent)
+	}
+}
+
+func TestCreateComment_NamedRangeFailure(t *testing.T) {
+	var postedBody map[string]any
+	cm := newTestCommentManager(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/v1/documents/test-file-id":
+			json.NewEncoder(w).Encode(&docs.Document{
+				Body: &docs.Body{Content: []*docs.StructuralElement{textParagraph(1, "title\n")}},
+			})
+		case r.Method == http.MethodPost && r.URL.Path == "/v1/documents/test-file-id:batchUpdate":
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(`{"error": {"code": 403, "message": "forbidden"}}`))
+		case r.Method == http.MethodPost && r.URL.Path == "/drive/v3/files/test-file-id/comments":
+			json.NewDecoder(r.Body).Decode(&postedBody)
+			json.NewEncoder(w).Encode(map[string]any{"id": "comment-123", "anchor": postedBody["anchor"]})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+
+	_, err := cm.CreateComment(context.Background(), &CommentRequest{
+		FileID:         "test-file-id",
+		Content:        "comment",
+		QuotedText:     "title",
+		AnchorStrategy: AnchorStrategyNamedRange,
+		Strict:         true,
+	})
+	if err == nil {
+		t.Fatal("CreateComment() error = nil, want error when the named range cannot be created")
+	}
+	if postedBody != nil {
+		t.Fatal("strict CreateComment() posted a comment although the named range could not be created")
+	}
+
+	// Without strict mode the comment is posted unanchored and reported as failed
+	resp, err := cm.CreateComment(context.Background(), &CommentRequest{
+		FileID:         "test-file-id",
+		Content:        "comment",
+		QuotedText:     "title",
+		AnchorStrategy: AnchorStrategyNamedRange,
+	})
+	if err != nil {
+		t.Fatalf("CreateComment() error = %v", err)
+	}
+	if resp.AnchorStatus != AnchorStatusFailed || resp.AnchorReason == "" {
+		t.Errorf("AnchorStatus = %q, reason %q, want %q with a reason", resp.AnchorStatus, resp.AnchorReason, AnchorStatusFailed)
+	}
+	if postedBody["anchor"] != nil || resp.Anchor != "" {
+		t.Errorf("posted anchor = %v, want no anchor when the named range cannot be created", postedBody["anchor"])
+	}
+	if resp.NamedRange != "" {
+		t.Errorf("NamedRange = %q, want empty", resp.NamedRange)
+	}
+}
+
+func TestCreateComment_NamedRangeKeepsCache(t *testing.T) {
+	var (
+		mu      sync.Mutex
+		fetches int
+		created int
+	)
+	// Every named range creates a new revision, as in Docs
+	revision := func() string { return fmt.Sprintf("rev-%d", created+1) }
+	cm := newTestCommentManager(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/v1/documents/test-file-id":
+			if r.URL.Query().Get("fields") == "revisionId" {
+				json.NewEncoder(w).Encode(&docs.Document{RevisionId: revision()})
+				return
+			}
+			fetches++
+			json.NewEncoder(w).Encode(&docs.Document{
+				RevisionId: revision(),
+				Body:       &docs.Body{Content: []*docs.StructuralElement{textParagraph(1, "alpha beta gamma\n")}},
+			})
+		case r.Method == http.MethodPost && r.URL.Path == "/v1/documents/test-file-id:batchUpdate":
+			var req docs.BatchUpdateDocumentRequest
+			json.NewDecoder(r.Body).Decode(&req)
+			if req.WriteControl == nil || req.WriteControl.RequiredRevisionId != revision() {
+				http.Error(w, "revision mismatch", http.StatusBadRequest)
+				return
+			}
+			created++
+			json.NewEncoder(w).Encode(&docs.BatchUpdateDocumentResponse{
+				Replies:      []*docs.Response{{CreateNamedRange: &docs.CreateNamedRangeResponse{NamedRangeId: fmt.Sprintf("kix.range%d", created)}}},
+				WriteControl: &docs.WriteControl{RequiredRevisionId: revision()},
+			})
+		case r.Method == http.MethodPost && r.URL.Path == "/drive/v3/files/test-file-id/comments":
+			json.NewEncoder(w).Encode(map[string]any{"id": "comment"})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+
+	names := make([]string, 0, 3)
+	for _, word := range []string{"alpha", "beta", "gamma"} {
+		resp, err := cm.CreateComment(context.Background(), &CommentRequest{
+			FileID:         "test-file-id",
+			Content:        word,
+			QuotedText:     word,
+			AnchorStrategy: AnchorStrategyNamedRange,
+		})
+		if err != nil {
+			t.Fatalf("CreateComment() error = %v", err)
+		}
+		if resp.AnchorStatus != AnchorStatusAnchored {
+			t.Fatalf("comment %q not anchored: %s", word, resp.AnchorReason)
+		}
+		names = append(names, resp.NamedRange)
+	}
+
+	// The created ranges are found in the cached document
+	positions, err := cm.FindNamedRange(context.Background(), "test-file-id", names[1])
+	if err != nil {
+		t.Fatalf("FindNamedRange() error = %v", err)
+	}
+	if len(positions) != 1 || positions[0].MatchedText != "beta" {
+		t.Errorf("FindNamedRange() = %+v, want the range of %q", positions, "beta")
+	}
+
+	if fetches != 1 {
+		t.Errorf("document fetched %d times, want 1", fetches)
+	}
+}
+
+func TestFindNamedRange(t *testing.T) {
+	doc := &docs.Document{
+		Body: &docs.Body{Content: []*docs.StructuralElement{
+			textParagraph(1, "intro title\n"),
+			textParagraph(13, "更新された本文\n"),
+		}},
+		NamedRanges: map[string]docs.NamedRanges{
+			"gdr-finding-1": {
+				Name: "gdr-finding-1",
+				NamedRanges: []*docs.NamedRange{
+					{Name: "gdr-finding-1", Ranges: []*docs.Range{{StartIndex: 7, EndIndex: 12}, {StartIndex: 13, EndIndex: 18}}},
+				},
+			},
+		},
+	}
+
+	tests := []struct {
+		name      string
+		rangeName string
+		want      []*TextPosition
+		wantErr   bool
+	}{
+		{
+			name:      "split range",
+			rangeName: "gdr-finding-1",
+			want: []*TextPosition{
+				{StartIndex: 7, EndIndex: 12, MatchedText: "title", Confidence: 1},
+				{StartIndex: 13, EndIndex: 18, MatchedText: "更新された", Confidence: 1},
+			},
+		},
+		{
+			name:      "unknown range",
+			rangeName: "gdr-finding-2",
+			wantErr:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := findNamedRange(doc, tt.rangeName)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("findNamedRange() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("findNamedRange() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
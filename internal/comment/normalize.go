@@ -0,0 +1,232 @@
+package comment
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// DefaultMinSimilarity is the similarity a normalized or fuzzy match needs to be accepted
+const DefaultMinSimilarity = 0.8
+
+// punctuationFolds maps punctuation variants to a canonical form, applied after NFKC
+var punctuationFolds = map[rune]rune{
+	'“': '"', '”': '"', '„': '"', '″': '"', '「': '"', '」': '"', '『': '"', '』': '"',
+	'‘': '\'', '’': '\'', '‚': '\'', '′': '\'',
+	'‐': '-', '‑': '-', '‒': '-', '–': '-', '—': '-', '―': '-', '−': '-',
+	'。': '.', '｡': '.', '、': ',', '､': ',',
+	'〜': '~',
+}
+
+// foldRune returns the folded form of a rune that is already NFKC normalized: punctuation folding
+// and lower case. Zero-width characters are dropped.
+func foldRune(r rune) (rune, bool) {
+	switch r {
+	case '\u200b', '\u200c', '\u200d', '\ufeff':
+		return 0, false
+	}
+
+	if folded, ok := punctuationFolds[r]; ok {
+		r = folded
+	}
+	return unicode.ToLower(r), true
+}
+
+// normalizedText is a normalized copy of a text that remembers the byte range of the original
+// text each normalized rune came from.
+type normalizedText struct {
+	runes       []rune
+	originStart []int
+	originEnd   []int
+}
+
+// normalizeText applies NFKC, punctuation folding and lower case to text and collapses whitespace
+// runs into a single space. NFKC runs over normalization segments rather than single runes, so a
+// half-width voiced mark composes with its kana ("ﾃﾞ" becomes "デ"); every rune of a segment
+// keeps the byte range of the whole segment in the original text.
+func normalizeText(text string) *normalizedText {
+	n := &normalizedText{}
+	lastSpace := true // Drop leading whitespace
+
+	var iter norm.Iter
+	iter.InitString(norm.NFKC, text)
+	for !iter.Done() {
+		start := iter.Pos()
+		segment := string(iter.Next())
+		end := iter.Pos()
+
+		for _, r := range segment {
+			c, ok := foldRune(r)
+			if !ok {
+				continue
+			}
+			if unicode.IsSpace(c) {
+				if lastSpace {
+					continue
+				}
+				c = ' '
+			}
+			n.append(c, start, end)
+			lastSpace = c == ' '
+		}
+	}
+
+	return n
+}
+
+// append adds a normalized rune that came from the original byte range [start, end)
+func (n *normalizedText) append(r rune, start, end int) {
+	n.runes = append(n.runes, r)
+	n.originStart = append(n.originStart, start)
+	n.originEnd = append(n.originEnd, end)
+}
+
+// normalizeQuery normalizes a search text and trims surrounding whitespace and punctuation,
+// such as a trailing "。" that is not part of the document.
+func normalizeQuery(text string) string {
+	normalized := string(normalizeText(text).runes)
+	return strings.TrimFunc(normalized, func(r rune) bool {
+		return unicode.IsSpace(r) || unicode.IsPunct(r)
+	})
+}
+
+// NormalizeQuote returns the form in which quoted texts are compared: NFKC, whitespace and
+// punctuation folding, lower case, and surrounding whitespace and punctuation trimmed. Quotes that
+// locate the same text in a document normally have the same normalized form.
+func NormalizeQuote(text string) string {
+	return normalizeQuery(text)
+}
+
+// normalizedString returns the normalized form of text without trimming
+func normalizedString(text string) string {
+	return strings.TrimSpace(string(normalizeText(text).runes))
+}
+
+// similarity returns 1 - edit distance / length of the longer input, compared after normalization
+func similarity(a, b string) float64 {
+	ra := []rune(normalizedString(a))
+	rb := []rune(normalizedString(b))
+
+	longest := max(len(ra), len(rb))
+	if longest == 0 {
+		return 1
+	}
+	return 1 - float64(levenshtein(ra, rb))/float64(longest)
+}
+
+// levenshtein returns the edit distance between two rune slices
+func levenshtein(a, b []rune) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+
+	return prev[len(b)]
+}
+
+// bestApproximateMatch finds the substring of text with the smallest edit distance to query
+// (Sellers' algorithm) and returns its rune range and distance.
+func bestApproximateMatch(text, query []rune) (start, end, distance int) {
+	m := len(query)
+	if m == 0 || len(text) == 0 {
+		return 0, 0, m
+	}
+
+	// cost[i] is the edit distance of query[:i] ending at the current text position,
+	// origin[i] is the text position where that alignment starts.
+	cost := make([]int, m+1)
+	origin := make([]int, m+1)
+	for i := range cost {
+		cost[i] = i
+	}
+
+	distance = m + 1
+	for j := 1; j <= len(text); j++ {
+		diagCost, diagOrigin := cost[0], origin[0]
+		cost[0], origin[0] = 0, j
+
+		for i := 1; i <= m; i++ {
+			upCost, upOrigin := cost[i], origin[i]
+
+			best, bestOrigin := diagCost, diagOrigin
+			if query[i-1] != text[j-1] {
+				best++
+			}
+			if upCost+1 < best {
+				best, bestOrigin = upCost+1, upOrigin
+			}
+			if cost[i-1]+1 < best {
+				best, bestOrigin = cost[i-1]+1, origin[i-1]
+			}
+
+			diagCost, diagOrigin = upCost, upOrigin
+			cost[i], origin[i] = best, bestOrigin
+		}
+
+		if cost[m] < distance {
+			distance, start, end = cost[m], origin[m], j
+		}
+	}
+
+	return start, end, distance
+}
+
+// findNormalized returns every occurrence of the normalized query in the normalized segment text
+func (s *segmentText) findNormalized(query string) []textMatch {
+	if query == "" {
+		return nil
+	}
+
+	normalized := normalizeText(s.String())
+	text := string(normalized.runes)
+	queryLen := utf8.RuneCountInString(query)
+
+	matches := make([]textMatch, 0)
+	for offset := 0; offset < len(text); {
+		i := strings.Index(text[offset:], query)
+		if i < 0 {
+			break
+		}
+
+		first := utf8.RuneCountInString(text[:offset+i])
+		last := first + queryLen - 1
+		matches = append(matches, textMatch{
+			segment: s,
+			start:   normalized.originStart[first],
+			end:     normalized.originEnd[last],
+		})
+		offset += i + len(query)
+	}
+
+	return matches
+}
+
+// findApproximate returns the closest fuzzy match of the normalized query in the segment
+func (s *segmentText) findApproximate(query string) (textMatch, bool) {
+	normalized := normalizeText(s.String())
+	start, end, _ := bestApproximateMatch(normalized.runes, []rune(query))
+	if end <= start {
+		return textMatch{}, false
+	}
+
+	return textMatch{
+		segment: s,
+		start:   normalized.originStart[start],
+		end:     normalized.originEnd[end-1],
+	}, true
+}
@@ -0,0 +1,162 @@
+package comment
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/api/docs/v1"
+)
+
+func TestNormalizeQuery(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{name: "full-width to half-width", input: "ＡＰＩ１２３", want: "api123"},
+		{name: "half-width katakana", input: "ﾃｽﾄ", want: "テスト"},
+		{name: "half-width voiced katakana", input: "ﾃﾞｰﾀﾍﾞｰｽのｶﾞｲﾄﾞとﾊﾟｽ", want: "データベースのガイドとパス"},
+		{name: "collapse whitespace", input: "  design \n\t doc  ", want: "design doc"},
+		{name: "smart quotes", input: "“quoted” and ‘single’", want: `quoted" and 'single`},
+		{name: "trailing period", input: "テストデザインドッグです。", want: "テストデザインドッグです"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if diff := cmp.Diff(tt.want, normalizeQuery(tt.input)); diff != "" {
+				t.Errorf("normalizeQuery() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestBestApproximateMatch(t *testing.T) {
+	text := []rune("the quick brown fox jumps")
+
+	start, end, distance := bestApproximateMatch(text, []rune("quick brwn"))
+	if got := string(text[start:end]); got != "quick brown" {
+		t.Errorf("bestApproximateMatch() matched %q, want %q", got, "quick brown")
+	}
+	if distance != 1 {
+		t.Errorf("bestApproximateMatch() distance = %d, want 1", distance)
+	}
+}
+
+func TestLocateText_Normalized(t *testing.T) {
+	doc := &docs.Document{
+		Body: &docs.Body{
+			Content: []*docs.StructuralElement{
+				textParagraph(1, "テストデザインドッグです\n"),
+				textParagraph(14, "APIの　仕様は“v2”です\n"),
+				textParagraph(29, "ガイドのデータベースとパス\n"),
+			},
+		},
+	}
+
+	tests := []struct {
+		name           string
+		query          *TextQuery
+		wantStart      int64
+		wantEnd        int64
+		wantMatched    string
+		wantConfidence float64
+		wantErr        bool
+	}{
+		{
+			name:           "exact",
+			query:          &TextQuery{Text: "デザイン"},
+			wantStart:      4,
+			wantEnd:        8,
+			wantMatched:    "デザイン",
+			wantConfidence: 1,
+		},
+		{
+			name:           "trailing punctuation",
+			query:          &TextQuery{Text: "テストデザインドッグです。"},
+			wantStart:      1,
+			wantEnd:        13,
+			wantMatched:    "テストデザインドッグです",
+			wantConfidence: 1,
+		},
+		{
+			name:           "short quote with trailing punctuation",
+			query:          &TextQuery{Text: "仕様。"},
+			wantStart:      19,
+			wantEnd:        21,
+			wantMatched:    "仕様",
+			wantConfidence: 1,
+		},
+		{
+			name:           "width, whitespace and quotes",
+			query:          &TextQuery{Text: "ＡＰＩの 仕様は\"v2\"です"},
+			wantStart:      14,
+			wantEnd:        28,
+			wantMatched:    "APIの　仕様は“v2”です",
+			wantConfidence: 1,
+		},
+		{
+			name:           "half-width voiced katakana",
+			query:          &TextQuery{Text: "ﾃﾞｰﾀﾍﾞｰｽ"},
+			wantStart:      33,
+			wantEnd:        39,
+			wantMatched:    "データベース",
+			wantConfidence: 1,
+		},
+		{
+			name:           "half-width voiced and semi-voiced marks",
+			query:          &TextQuery{Text: "ｶﾞｲﾄﾞ"},
+			wantStart:      29,
+			wantEnd:        32,
+			wantMatched:    "ガイド",
+			wantConfidence: 1,
+		},
+		{
+			name:           "half-width semi-voiced katakana",
+			query:          &TextQuery{Text: "ﾊﾟｽ"},
+			wantStart:      40,
+			wantEnd:        42,
+			wantMatched:    "パス",
+			wantConfidence: 1,
+		},
+		{
+			name:           "fuzzy typo",
+			query:          &TextQuery{Text: "テストデザインドックです"},
+			wantStart:      1,
+			wantEnd:        13,
+			wantMatched:    "テストデザインドッグです",
+			wantConfidence: 1 - 1.0/12,
+		},
+		{
+			name:    "below threshold",
+			query:   &TextQuery{Text: "テストデザインドックです", MinSimilarity: 0.95},
+			wantErr: true,
+		},
+		{
+			name:    "unrelated text",
+			query:   &TextQuery{Text: "まったく関係のない文章"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := locateText(doc, tt.query)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("locateText() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+
+			if got.StartIndex != tt.wantStart || got.EndIndex != tt.wantEnd {
+				t.Errorf("locateText() range = [%d, %d), want [%d, %d)", got.StartIndex, got.EndIndex, tt.wantStart, tt.wantEnd)
+			}
+			if got.MatchedText != tt.wantMatched {
+				t.Errorf("locateText() matched = %q, want %q", got.MatchedText, tt.wantMatched)
+			}
+			if diff := got.Confidence - tt.wantConfidence; diff > 1e-9 || diff < -1e-9 {
+				t.Errorf("locateText() confidence = %v, want %v", got.Confidence, tt.wantConfidence)
+			}
+		})
+	}
+}
@@ -0,0 +1,90 @@
+package comment
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// CommentSignature is appended to every comment this tool posts, so its comments can be told
+// apart from human reviewers' comments
+const CommentSignature = "— google-doc-review"
+
+// signContent appends the signature to a comment body
+func signContent(content string) string {
+	return content + "\n\n" + CommentSignature
+}
+
+// hasSignature reports whether a comment body ends with the signature
+func hasSignature(content string) bool {
+	return strings.HasSuffix(strings.TrimSpace(content), CommentSignature)
+}
+
+// stripSignature returns the comment body without the signature, the idempotency marker and the named range note
+func stripSignature(content string) string {
+	content = strings.TrimSuffix(strings.TrimSpace(content), CommentSignature)
+	content = removeIdempotencyMarker(strings.TrimSpace(content))
+	lines := strings.Split(strings.TrimSpace(content), "\n")
+	if strings.HasPrefix(lines[len(lines)-1], namedRangeNotePrefix) {
+		lines = lines[:len(lines)-1]
+	}
+	return strings.TrimSpace(strings.Join(lines, "\n"))
+}
+
+// IsToolComment reports whether a comment was posted by this tool as the authenticated user
+func IsToolComment(c *Comment) bool {
+	return c.Author.Me && hasSignature(c.Content)
+}
+
+// PurgeOptions controls PurgeBotComments
+type PurgeOptions struct {
+	Confirm bool // Delete the matched comments; without it they are only listed
+}
+
+// PurgeResult is the result of purging the tool's comments
+type PurgeResult struct {
+	Matched []*Comment // Comments authored by this tool
+	Deleted []*Comment // Comments actually deleted, empty unless confirmed
+	Errors  []error    // Comments that could not be deleted
+}
+
+// PurgeBotComments deletes the comments this tool posted on a document. Only comments authored by
+// the authenticated user and carrying CommentSignature are touched; human reviewers' comments,
+// and the user's own handwritten ones, are kept. Nothing is deleted unless opts.Confirm is set;
+// by default the matched comments are only listed.
+func (cm *CommentManager) PurgeBotComments(ctx context.Context, fileID string, opts *PurgeOptions) (*PurgeResult, error) {
+	if opts == nil {
+		opts = &PurgeOptions{}
+	}
+
+	comments, err := cm.ListComments(ctx, fileID, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &PurgeResult{}
+	for _, c := range comments {
+		if !c.Deleted && IsToolComment(c) {
+			result.Matched = append(result.Matched, c)
+		}
+	}
+
+	if !opts.Confirm {
+		return result, nil
+	}
+
+	for _, c := range result.Matched {
+		if err := cm.DeleteComment(ctx, fileID, c.ID); err != nil {
+			result.Errors = append(result.Errors, fmt.Errorf("comment %s: %w", c.ID, err))
+			continue
+		}
+		result.Deleted = append(result.Deleted, c)
+	}
+
+	if len(result.Errors) > 0 {
+		return result, fmt.Errorf("failed to delete %d comments: %w", len(result.Errors), errors.Join(result.Errors...))
+	}
+
+	return result, nil
+}
@@ -0,0 +1,164 @@
+package comment
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestIsToolComment(t *testing.T) {
+	tests := []struct {
+		name    string
+		comment *Comment
+		want    bool
+	}{
+		{
+			name:    "signed by the authenticated user",
+			comment: &Comment{Content: signContent("typo"), Author: Author{Me: true}},
+			want:    true,
+		},
+		{
+			name:    "signed with a named range note",
+			comment: &Comment{Content: signContent("typo\n\n" + namedRangeNote("gdr-finding-1")), Author: Author{Me: true}},
+			want:    true,
+		},
+		{
+			name:    "unsigned comment by the authenticated user",
+			comment: &Comment{Content: "typo", Author: Author{Me: true}},
+			want:    false,
+		},
+		{
+			name:    "signed comment by another user",
+			comment: &Comment{Content: signContent("typo"), Author: Author{DisplayName: "Alice"}},
+			want:    false,
+		},
+		{
+			name:    "signature quoted in the middle",
+			comment: &Comment{Content: CommentSignature + " posted this, please check", Author: Author{Me: true}},
+			want:    false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsToolComment(tt.comment); got != tt.want {
+				t.Errorf("IsToolComment() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStripSignature(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{name: "signed", content: signContent("typo"), want: "typo"},
+		{name: "signed with named range", content: signContent("typo\n\n" + namedRangeNote("gdr-finding-1")), want: "typo"},
+		{name: "unsigned", content: "typo", want: "typo"},
+		{name: "multi line", content: signContent("typo\nsecond line"), want: "typo\nsecond line"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := stripSignature(tt.content); got != tt.want {
+				t.Errorf("stripSignature() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPurgeBotComments(t *testing.T) {
+	tests := []struct {
+		name        string
+		opts        *PurgeOptions
+		failDelete  string
+		wantMatched []string
+		wantDeleted []string
+		wantErr     bool
+	}{
+		{
+			name:        "no options only lists",
+			wantMatched: []string{"bot-1", "bot-2"},
+		},
+		{
+			name:        "unconfirmed deletes nothing",
+			opts:        &PurgeOptions{},
+			wantMatched: []string{"bot-1", "bot-2"},
+		},
+		{
+			name:        "delete only tool comments",
+			opts:        &PurgeOptions{Confirm: true},
+			wantMatched: []string{"bot-1", "bot-2"},
+			wantDeleted: []string{"bot-1", "bot-2"},
+		},
+		{
+			name:        "delete failure is reported",
+			opts:        &PurgeOptions{Confirm: true},
+			failDelete:  "bot-1",
+			wantMatched: []string{"bot-1", "bot-2"},
+			wantDeleted: []string{"bot-2"},
+			wantErr:     true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var deleted []string
+			cm := newTestCommentManager(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				switch {
+				case r.Method == http.MethodGet && r.URL.Path == "/drive/v3/files/test-file-id/comments":
+					json.NewEncoder(w).Encode(map[string]any{
+						"comments": []map[string]any{
+							{"id": "bot-1", "content": signContent("typo"), "author": map[string]any{"me": true}},
+							{"id": "human-1", "content": signContent("typo"), "author": map[string]any{"displayName": "Alice"}},
+							{"id": "own-1", "content": "handwritten note", "author": map[string]any{"me": true}},
+							{"id": "bot-deleted", "content": signContent("typo"), "author": map[string]any{"me": true}, "deleted": true},
+							{"id": "bot-2", "content": signContent("missing section"), "author": map[string]any{"me": true}},
+						},
+					})
+				case r.Method == http.MethodDelete && strings.HasPrefix(r.URL.Path, "/drive/v3/files/test-file-id/comments/"):
+					id := strings.TrimPrefix(r.URL.Path, "/drive/v3/files/test-file-id/comments/")
+					if id == tt.failDelete {
+						http.Error(w, "internal error", http.StatusInternalServerError)
+						return
+					}
+					deleted = append(deleted, id)
+					w.WriteHeader(http.StatusNoContent)
+				default:
+					http.NotFound(w, r)
+				}
+			}))
+
+			result, err := cm.PurgeBotComments(context.Background(), "test-file-id", tt.opts)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("PurgeBotComments() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if diff := cmp.Diff(tt.wantMatched, commentIDs(result.Matched)); diff != "" {
+				t.Errorf("matched comments mismatch (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(tt.wantDeleted, commentIDs(result.Deleted)); diff != "" {
+				t.Errorf("deleted comments mismatch (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(tt.wantDeleted, deleted); diff != "" {
+				t.Errorf("delete requests mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+// commentIDs returns the IDs of comments
+func commentIDs(comments []*Comment) []string {
+	var ids []string
+	for _, c := range comments {
+		ids = append(ids, c.ID)
+	}
+	return ids
+}
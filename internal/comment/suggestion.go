@@ -0,0 +1,310 @@
+package comment
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"google.golang.org/api/docs/v1"
+	"google.golang.org/api/drive/v3"
+)
+
+// Edit is a concrete replacement of text in a document, such as the fix for a typo.
+//
+// The Docs API cannot create tracked suggestions, so edits are applied as a documents.batchUpdate,
+// by default on a copy of the document so the original is left untouched.
+type Edit struct {
+	QuotedText    string `json:"quoted_text"`              // Text to replace
+	Replacement   string `json:"replacement"`              // New text, empty to delete QuotedText
+	Occurrence    int    `json:"occurrence,omitempty"`     // Which occurrence of QuotedText is meant
+	ContextBefore string `json:"context_before,omitempty"` // Text immediately before QuotedText
+	ContextAfter  string `json:"context_after,omitempty"`  // Text immediately after QuotedText
+	ReplaceAll    bool   `json:"replace_all,omitempty"`    // Replace every exact occurrence of QuotedText
+}
+
+// Validate checks that the edit names the text to replace and changes it
+func (e Edit) Validate() error {
+	if e.QuotedText == "" {
+		return fmt.Errorf("edit quoted_text must not be empty")
+	}
+	if e.QuotedText == e.Replacement {
+		return fmt.Errorf("edit replacement is the same as quoted_text")
+	}
+	return nil
+}
+
+// textQuery returns the query used to locate the text of the edit
+func (e Edit) textQuery() *TextQuery {
+	return &TextQuery{
+		Text:          e.QuotedText,
+		Occurrence:    e.Occurrence,
+		ContextBefore: e.ContextBefore,
+		ContextAfter:  e.ContextAfter,
+	}
+}
+
+// EditPreview is the located target of an edit and how the text reads before and after it
+type EditPreview struct {
+	Index       int
+	Edit        Edit
+	Positions   []*TextPosition // Located text, one per occurrence for ReplaceAll edits
+	Diff        string          // Lines prefixed with "-" and "+" showing the text around each change
+	Err         error           // Why the edit cannot be applied
+	replaceText bool            // Whether the edit is applied with replaceAllText
+}
+
+// SuggestionPreview is the result of locating a set of edits in a document
+type SuggestionPreview struct {
+	DocumentID string
+	RevisionID string // Revision the preview was computed against
+	Edits      []*EditPreview
+}
+
+// Diff returns the diff of every edit that can be applied
+func (p *SuggestionPreview) Diff() string {
+	var builder strings.Builder
+	for _, edit := range p.Edits {
+		if edit.Err == nil {
+			builder.WriteString(edit.Diff)
+		}
+	}
+	return builder.String()
+}
+
+// Failed returns the number of edits that cannot be applied
+func (p *SuggestionPreview) Failed() int {
+	failed := 0
+	for _, edit := range p.Edits {
+		if edit.Err != nil {
+			failed++
+		}
+	}
+	return failed
+}
+
+// ApplyOptions controls where edits are applied
+type ApplyOptions struct {
+	InPlace    bool   // Edit the document itself instead of a copy
+	CopyTitle  string // Title of the copy (optional, defaults to the document title with a suffix)
+	RevisionID string // Refuse to apply unless the document is still at this revision, as returned by the preview (optional)
+}
+
+// ApplyResult is the result of applying edits
+type ApplyResult struct {
+	DocumentID string // Document the edits were applied to
+	Copied     bool   // Whether DocumentID is a copy of the original
+	Applied    int
+	Preview    *SuggestionPreview
+}
+
+// PreviewSuggestions locates each edit in a document and returns the resulting diff without changing it
+func (cm *CommentManager) PreviewSuggestions(ctx context.Context, fileID string, edits []Edit) (*SuggestionPreview, error) {
+	doc, err := cm.docsService.Documents.Get(fileID).Context(ctx).Do()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get document: %w", err)
+	}
+
+	return previewEdits(doc, edits), nil
+}
+
+// ApplySuggestions applies edits to a copy of a document, or to the document itself with InPlace.
+// Edits that cannot be located are skipped and reported in the preview of the result. If the edits
+// fail once the copy exists, the result naming the unedited copy is returned with the error.
+func (cm *CommentManager) ApplySuggestions(ctx context.Context, fileID string, edits []Edit, opts *ApplyOptions) (*ApplyResult, error) {
+	if opts == nil {
+		opts = &ApplyOptions{}
+	}
+
+	// Fetched without the cache, as the revision must be current to guard the edits
+	doc, err := cm.docsService.Documents.Get(fileID).Context(ctx).Do()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get document: %w", err)
+	}
+	if opts.RevisionID != "" && doc.RevisionId != opts.RevisionID {
+		return nil, fmt.Errorf("document changed since the preview: revision %s, expected %s", doc.RevisionId, opts.RevisionID)
+	}
+
+	result := &ApplyResult{DocumentID: fileID}
+	if !opts.InPlace {
+		title := opts.CopyTitle
+		if title == "" {
+			title = doc.Title + " (suggested edits)"
+		}
+
+		copied, err := cm.driveService.Files.Copy(fileID, &drive.File{Name: title}).Context(ctx).Fields("id").Do()
+		if err != nil {
+			return nil, fmt.Errorf("failed to copy document: %w", err)
+		}
+		result.DocumentID = copied.Id
+		result.Copied = true
+
+		// Locate the edits in the copy, which is the document being changed
+		doc, err = cm.docsService.Documents.Get(copied.Id).Context(ctx).Do()
+		if err != nil {
+			return result, fmt.Errorf("failed to get copied document %s: %w", copied.Id, err)
+		}
+	}
+
+	result.Preview = previewEdits(doc, edits)
+	requests := editRequests(result.Preview)
+	if len(requests) == 0 {
+		return result, nil
+	}
+
+	_, err = cm.docsService.Documents.BatchUpdate(result.DocumentID, &docs.BatchUpdateDocumentRequest{
+		Requests:     requests,
+		WriteControl: &docs.WriteControl{RequiredRevisionId: doc.RevisionId},
+	}).Context(ctx).Do()
+	cm.documents.Invalidate(result.DocumentID)
+	if err != nil {
+		if result.Copied {
+			return result, fmt.Errorf("failed to apply edits to copy %s: %w", result.DocumentID, err)
+		}
+		return nil, fmt.Errorf("failed to apply edits: %w", err)
+	}
+
+	result.Applied = len(result.Preview.Edits) - result.Preview.Failed()
+	return result, nil
+}
+
+// previewEdits locates every edit in a document. Edits whose text overlaps an earlier edit fail.
+func previewEdits(doc *docs.Document, edits []Edit) *SuggestionPreview {
+	preview := &SuggestionPreview{
+		DocumentID: doc.DocumentId,
+		RevisionID: doc.RevisionId,
+		Edits:      make([]*EditPreview, 0, len(edits)),
+	}
+
+	located := make([]*EditPreview, 0, len(edits))
+	for i, edit := range edits {
+		p := previewEdit(doc, i, edit)
+		if p.Err == nil {
+			if other := overlappingEdit(located, p); other != nil {
+				p.Err = fmt.Errorf("edit %d overlaps edit %d", i, other.Index)
+			} else {
+				located = append(located, p)
+			}
+		}
+		preview.Edits = append(preview.Edits, p)
+	}
+
+	return preview
+}
+
+// previewEdit locates a single edit and renders its diff
+func previewEdit(doc *docs.Document, index int, edit Edit) *EditPreview {
+	p := &EditPreview{Index: index, Edit: edit}
+	if err := edit.Validate(); err != nil {
+		p.Err = err
+		return p
+	}
+
+	var matches []textMatch
+	if edit.ReplaceAll {
+		// replaceAllText only replaces exact text
+		matches = findTextMatches(doc, edit.QuotedText)
+		if len(matches) == 0 {
+			p.Err = fmt.Errorf("%w: %s", ErrTextNotFound, edit.QuotedText)
+			return p
+		}
+		p.replaceText = true
+	} else {
+		match, err := locateMatch(doc, edit.textQuery())
+		if err != nil {
+			p.Err = err
+			return p
+		}
+		// Only comments may use approximate matches; an edit must not change text other than what was quoted
+		if normalizedString(match.Text()) != normalizedString(edit.QuotedText) {
+			p.Err = fmt.Errorf("%w: closest match %q differs from quoted_text %q, edits require an exact match", ErrTextNotFound, match.Text(), edit.QuotedText)
+			return p
+		}
+		matches = []textMatch{match}
+	}
+
+	var diff strings.Builder
+	for _, match := range matches {
+		position := match.Position()
+		position.Confidence = match.Similarity(edit.QuotedText)
+		p.Positions = append(p.Positions, position)
+
+		before := diffContext(match.Before(candidateContextLength))
+		after := diffContext(match.After(candidateContextLength))
+		fmt.Fprintf(&diff, "@@ edit %d [%d-%d) @@\n", index, position.StartIndex, position.EndIndex)
+		fmt.Fprintf(&diff, "- …%s%s%s…\n", before, diffContext(match.Text()), after)
+		fmt.Fprintf(&diff, "+ …%s%s%s…\n", before, diffContext(edit.Replacement), after)
+	}
+	p.Diff = diff.String()
+
+	return p
+}
+
+// diffContext keeps a diff line on one line by escaping line breaks
+func diffContext(text string) string {
+	return strings.ReplaceAll(text, "\n", `\n`)
+}
+
+// overlappingEdit returns the located edit whose text overlaps p, if any
+func overlappingEdit(located []*EditPreview, p *EditPreview) *EditPreview {
+	for _, other := range located {
+		for _, a := range other.Positions {
+			for _, b := range p.Positions {
+				if a.SegmentID == b.SegmentID && a.StartIndex < b.EndIndex && b.StartIndex < a.EndIndex {
+					return other
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// editRequests builds the batchUpdate requests for the located edits of a preview.
+// Single edits are applied from the end of the document backwards so earlier indexes stay valid,
+// followed by the replaceAllText requests.
+func editRequests(preview *SuggestionPreview) []*docs.Request {
+	single := make([]*EditPreview, 0, len(preview.Edits))
+	replaceAll := make([]*EditPreview, 0)
+	for _, p := range preview.Edits {
+		switch {
+		case p.Err != nil:
+		case p.replaceText:
+			replaceAll = append(replaceAll, p)
+		default:
+			single = append(single, p)
+		}
+	}
+
+	sort.SliceStable(single, func(i, j int) bool {
+		return single[i].Positions[0].StartIndex > single[j].Positions[0].StartIndex
+	})
+
+	requests := make([]*docs.Request, 0, 2*len(single)+len(replaceAll))
+	for _, p := range single {
+		pos := p.Positions[0]
+		requests = append(requests, &docs.Request{
+			DeleteContentRange: &docs.DeleteContentRangeRequest{
+				Range: &docs.Range{SegmentId: pos.SegmentID, StartIndex: pos.StartIndex, EndIndex: pos.EndIndex},
+			},
+		})
+		if p.Edit.Replacement != "" {
+			requests = append(requests, &docs.Request{
+				InsertText: &docs.InsertTextRequest{
+					Location: &docs.Location{SegmentId: pos.SegmentID, Index: pos.StartIndex},
+					Text:     p.Edit.Replacement,
+				},
+			})
+		}
+	}
+
+	for _, p := range replaceAll {
+		requests = append(requests, &docs.Request{
+			ReplaceAllText: &docs.ReplaceAllTextRequest{
+				ContainsText: &docs.SubstringMatchCriteria{Text: p.Edit.QuotedText, MatchCase: true},
+				ReplaceText:  p.Edit.Replacement,
+			},
+		})
+	}
+
+	return requests
+}
@@ -0,0 +1,281 @@
+package comment
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/api/docs/v1"
+)
+
+// suggestionTestDocument returns a document with a typo in the title and a repeated word
+func suggestionTestDocument() *docs.Document {
+	return &docs.Document{
+		DocumentId: "test-file-id",
+		RevisionId: "rev-1",
+		Title:      "Design Doc",
+		Body: &docs.Body{Content: []*docs.StructuralElement{
+			textParagraph(1, "テストデザインドッグ\n"),
+			textParagraph(12, "テストテスト\n"),
+		}},
+	}
+}
+
+func TestPreviewEdits(t *testing.T) {
+	type editSummary struct {
+		Index     int
+		Positions [][2]int64
+		Diff      string
+		Failed    bool
+	}
+
+	tests := []struct {
+		name  string
+		edits []Edit
+		want  []editSummary
+	}{
+		{
+			name:  "typo",
+			edits: []Edit{{QuotedText: "ドッグ", Replacement: "ドキュメント"}},
+			want: []editSummary{{
+				Index:     0,
+				Positions: [][2]int64{{8, 11}},
+				Diff:      "@@ edit 0 [8-11) @@\n- …テストデザインドッグ\\nテストテスト\\n…\n+ …テストデザインドキュメント\\nテストテスト\\n…\n",
+			}},
+		},
+		{
+			name:  "replace all",
+			edits: []Edit{{QuotedText: "テスト", Replacement: "試験", ReplaceAll: true}},
+			want: []editSummary{{
+				Index:     0,
+				Positions: [][2]int64{{1, 4}, {12, 15}, {15, 18}},
+				Diff: "@@ edit 0 [1-4) @@\n- …テストデザインドッグ\\nテストテスト\\n…\n+ …試験デザインドッグ\\nテストテスト\\n…\n" +
+					"@@ edit 0 [12-15) @@\n- …テストデザインドッグ\\nテストテスト\\n…\n+ …テストデザインドッグ\\n試験テスト\\n…\n" +
+					"@@ edit 0 [15-18) @@\n- …テストデザインドッグ\\nテストテスト\\n…\n+ …テストデザインドッグ\\nテスト試験\\n…\n",
+			}},
+		},
+		{
+			name:  "ambiguous",
+			edits: []Edit{{QuotedText: "テスト", Replacement: "試験"}},
+			want:  []editSummary{{Index: 0, Failed: true}},
+		},
+		{
+			name:  "fuzzy match",
+			edits: []Edit{{QuotedText: "デザインドック", Replacement: "デザイン"}},
+			want:  []editSummary{{Index: 0, Failed: true}},
+		},
+		{
+			name:  "trailing punctuation not in document",
+			edits: []Edit{{QuotedText: "ドッグ。", Replacement: "ドキュメント。"}},
+			want:  []editSummary{{Index: 0, Failed: true}},
+		},
+		{
+			name:  "no change",
+			edits: []Edit{{QuotedText: "ドッグ", Replacement: "ドッグ"}},
+			want:  []editSummary{{Index: 0, Failed: true}},
+		},
+		{
+			name: "overlapping edits",
+			edits: []Edit{
+				{QuotedText: "デザインドッグ", Replacement: "デザインドキュメント"},
+				{QuotedText: "ドッグ", Replacement: "ドキュメント"},
+			},
+			want: []editSummary{
+				{Index: 0, Positions: [][2]int64{{4, 11}}, Diff: "@@ edit 0 [4-11) @@\n- …テストデザインドッグ\\nテストテスト\\n…\n+ …テストデザインドキュメント\\nテストテスト\\n…\n"},
+				{Index: 1, Positions: [][2]int64{{8, 11}}, Failed: true},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			preview := previewEdits(suggestionTestDocument(), tt.edits)
+
+			got := make([]editSummary, 0, len(preview.Edits))
+			for _, p := range preview.Edits {
+				summary := editSummary{Index: p.Index, Failed: p.Err != nil}
+				for _, pos := range p.Positions {
+					summary.Positions = append(summary.Positions, [2]int64{pos.StartIndex, pos.EndIndex})
+				}
+				if p.Err == nil {
+					summary.Diff = p.Diff
+				}
+				got = append(got, summary)
+			}
+
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("previewEdits() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestEditRequests_Order(t *testing.T) {
+	preview := previewEdits(suggestionTestDocument(), []Edit{
+		{QuotedText: "テスト", Replacement: "", Occurrence: 1},
+		{QuotedText: "ドッグ", Replacement: "ドキュメント", ReplaceAll: true},
+		{QuotedText: "テスト", Replacement: "試験", Occurrence: 3},
+	})
+
+	want := []*docs.Request{
+		{DeleteContentRange: &docs.DeleteContentRangeRequest{Range: &docs.Range{StartIndex: 15, EndIndex: 18}}},
+		{InsertText: &docs.InsertTextRequest{Location: &docs.Location{Index: 15}, Text: "試験"}},
+		{DeleteContentRange: &docs.DeleteContentRangeRequest{Range: &docs.Range{StartIndex: 1, EndIndex: 4}}},
+		{ReplaceAllText: &docs.ReplaceAllTextRequest{
+			ContainsText: &docs.SubstringMatchCriteria{Text: "ドッグ", MatchCase: true},
+			ReplaceText:  "ドキュメント",
+		}},
+	}
+	if diff := cmp.Diff(want, editRequests(preview)); diff != "" {
+		t.Errorf("editRequests() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestApplySuggestions(t *testing.T) {
+	tests := []struct {
+		name         string
+		opts         *ApplyOptions
+		wantDocument string
+		wantCopied   bool
+		failUpdate   bool
+		wantErr      bool
+	}{
+		{
+			name:         "copy by default",
+			wantDocument: "copy-file-id",
+			wantCopied:   true,
+		},
+		{
+			name:         "in place",
+			opts:         &ApplyOptions{InPlace: true, RevisionID: "rev-1"},
+			wantDocument: "test-file-id",
+		},
+		{
+			name:    "document changed since preview",
+			opts:    &ApplyOptions{RevisionID: "rev-0"},
+			wantErr: true,
+		},
+		{
+			name:         "failed update reports the copy",
+			failUpdate:   true,
+			wantDocument: "copy-file-id",
+			wantCopied:   true,
+			wantErr:      true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				copyName  string
+				updatedID string
+				update    docs.BatchUpdateDocumentRequest
+			)
+			cm := newTestCommentManager(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				switch {
+				case r.Method == http.MethodGet && r.URL.Path == "/v1/documents/test-file-id":
+					json.NewEncoder(w).Encode(suggestionTestDocument())
+				case r.Method == http.MethodGet && r.URL.Path == "/v1/documents/copy-file-id":
+					doc := suggestionTestDocument()
+					doc.DocumentId, doc.RevisionId = "copy-file-id", "copy-rev-1"
+					json.NewEncoder(w).Encode(doc)
+				case r.Method == http.MethodPost && r.URL.Path == "/drive/v3/files/test-file-id/copy":
+					var body map[string]any
+					json.NewDecoder(r.Body).Decode(&body)
+					copyName, _ = body["name"].(string)
+					json.NewEncoder(w).Encode(map[string]any{"id": "copy-file-id"})
+				case r.Method == http.MethodPost && (r.URL.Path == "/v1/documents/test-file-id:batchUpdate" || r.URL.Path == "/v1/documents/copy-file-id:batchUpdate"):
+					if tt.failUpdate {
+						http.Error(w, "internal error", http.StatusInternalServerError)
+						return
+					}
+					updatedID = r.URL.Path
+					json.NewDecoder(r.Body).Decode(&update)
+					json.NewEncoder(w).Encode(map[string]any{})
+				default:
+					http.NotFound(w, r)
+				}
+			}))
+
+			result, err := cm.ApplySuggestions(context.Background(), "test-file-id", []Edit{
+				{QuotedText: "ドッグ", Replacement: "ドキュメント"},
+				{QuotedText: "missing", Replacement: "text"},
+			}, tt.opts)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ApplySuggestions() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				if updatedID != "" {
+					t.Errorf("document was updated at %s, want no update", updatedID)
+				}
+				if tt.wantDocument == "" {
+					if result != nil {
+						t.Errorf("result = %+v, want nil", result)
+					}
+				} else if result == nil || result.DocumentID != tt.wantDocument || result.Copied != tt.wantCopied {
+					t.Errorf("result = %+v, want copy %s", result, tt.wantDocument)
+				}
+				return
+			}
+
+			if result.DocumentID != tt.wantDocument || result.Copied != tt.wantCopied {
+				t.Errorf("result = (%s, copied %v), want (%s, copied %v)", result.DocumentID, result.Copied, tt.wantDocument, tt.wantCopied)
+			}
+			if result.Applied != 1 || result.Preview.Failed() != 1 {
+				t.Errorf("applied = %d, failed = %d, want 1 and 1", result.Applied, result.Preview.Failed())
+			}
+			if want := "/v1/documents/" + tt.wantDocument + ":batchUpdate"; updatedID != want {
+				t.Errorf("batchUpdate sent to %s, want %s", updatedID, want)
+			}
+			if tt.wantCopied && copyName != "Design Doc (suggested edits)" {
+				t.Errorf("copy name = %q, want %q", copyName, "Design Doc (suggested edits)")
+			}
+
+			wantRevision := "rev-1"
+			if tt.wantCopied {
+				wantRevision = "copy-rev-1"
+			}
+			if update.WriteControl == nil || update.WriteControl.RequiredRevisionId != wantRevision {
+				t.Errorf("write control = %+v, want required revision %s", update.WriteControl, wantRevision)
+			}
+			if len(update.Requests) != 2 {
+				t.Errorf("got %d requests, want delete and insert", len(update.Requests))
+			}
+		})
+	}
+}
+
+func TestIssue_Edit(t *testing.T) {
+	tests := []struct {
+		name   string
+		issue  Issue
+		want   Edit
+		wantOK bool
+	}{
+		{
+			name:   "with replacement",
+			issue:  Issue{TextContent: "ドッグ", Replacement: "ドキュメント", Occurrence: 2},
+			want:   Edit{QuotedText: "ドッグ", Replacement: "ドキュメント", Occurrence: 2},
+			wantOK: true,
+		},
+		{
+			name:  "suggestion only",
+			issue: Issue{TextContent: "ドッグ", Suggestion: "「ドキュメント」に修正してください"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := tt.issue.Edit()
+			if ok != tt.wantOK {
+				t.Fatalf("Edit() ok = %v, want %v", ok, tt.wantOK)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("Edit() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
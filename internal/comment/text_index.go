@@ -0,0 +1,378 @@
+package comment
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"google.golang.org/api/docs/v1"
+)
+
+// segmentText is the linearized text of one document segment (body, header, footer or footnote)
+// together with the Docs index of every rune. Docs indexes count UTF-16 code units.
+type segmentText struct {
+	SegmentID string
+	text      strings.Builder
+	offsets   []int   // Byte offset of each rune in text
+	indexes   []int64 // Docs start index of each rune
+	ends      []int64 // Docs end index of each rune
+}
+
+// linearizeDocument returns the text of the body followed by headers, footers and footnotes.
+// Segments other than the body are sorted by ID so the order is stable.
+func linearizeDocument(doc *docs.Document) []*segmentText {
+	segments := make([]*segmentText, 0, 1+len(doc.Headers)+len(doc.Footers)+len(doc.Footnotes))
+
+	body := &segmentText{}
+	if doc.Body != nil {
+		body.appendElements(doc.Body.Content)
+	}
+	segments = append(segments, body)
+
+	for _, id := range sortedKeys(doc.Headers) {
+		segment := &segmentText{SegmentID: id}
+		segment.appendElements(doc.Headers[id].Content)
+		segments = append(segments, segment)
+	}
+
+	for _, id := range sortedKeys(doc.Footers) {
+		segment := &segmentText{SegmentID: id}
+		segment.appendElements(doc.Footers[id].Content)
+		segments = append(segments, segment)
+	}
+
+	for _, id := range sortedKeys(doc.Footnotes) {
+		segment := &segmentText{SegmentID: id}
+		segment.appendElements(doc.Footnotes[id].Content)
+		segments = append(segments, segment)
+	}
+
+	return segments
+}
+
+// sortedKeys returns the keys of a map in ascending order
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// appendElements appends the text of structural elements, descending into tables and tables of contents
+func (s *segmentText) appendElements(elements []*docs.StructuralElement) {
+	for _, element := range elements {
+		if element.Paragraph != nil {
+			for _, elem := range element.Paragraph.Elements {
+				if elem.TextRun != nil {
+					s.appendRun(elem.TextRun.Content, elem.StartIndex)
+				}
+			}
+		}
+
+		if element.Table != nil {
+			for _, row := range element.Table.TableRows {
+				for _, cell := range row.TableCells {
+					s.appendElements(cell.Content)
+				}
+			}
+		}
+
+		if element.TableOfContents != nil {
+			s.appendElements(element.TableOfContents.Content)
+		}
+	}
+}
+
+// appendRun appends the content of a text run starting at the given Docs index
+func (s *segmentText) appendRun(content string, startIndex int64) {
+	index := startIndex
+	for _, r := range content {
+		// Soft line breaks are searched as regular line breaks
+		if r == '\v' {
+			r = '\n'
+		}
+
+		width := int64(utf16.RuneLen(r))
+		if width < 0 {
+			width = 1
+		}
+
+		s.offsets = append(s.offsets, s.text.Len())
+		s.indexes = append(s.indexes, index)
+		s.ends = append(s.ends, index+width)
+		s.text.WriteRune(r)
+		index += width
+	}
+}
+
+// String returns the linearized text of the segment
+func (s *segmentText) String() string {
+	return s.text.String()
+}
+
+// position converts a byte range of the linearized text into a Docs index range
+func (s *segmentText) position(start, end int) *TextPosition {
+	first := sort.SearchInts(s.offsets, start)
+	last := sort.SearchInts(s.offsets, end) - 1
+
+	return &TextPosition{
+		StartIndex: s.indexes[first],
+		EndIndex:   s.ends[last],
+		SegmentID:  s.SegmentID,
+	}
+}
+
+// textMatch is an occurrence of a search string in a segment, as a byte range of the linearized text
+type textMatch struct {
+	segment *segmentText
+	start   int
+	end     int
+}
+
+// Position returns the Docs index range of the match
+func (m textMatch) Position() *TextPosition {
+	position := m.segment.position(m.start, m.end)
+	position.MatchedText = m.Text()
+	return position
+}
+
+// Text returns the matched text as it appears in the document
+func (m textMatch) Text() string {
+	return m.segment.String()[m.start:m.end]
+}
+
+// Similarity returns how close the matched text is to the searched text, from 0 to 1.
+// Both texts are compared as normalized queries, so surrounding whitespace and punctuation
+// that normalization trims do not lower the score.
+func (m textMatch) Similarity(searchText string) float64 {
+	if m.Text() == searchText {
+		return 1
+	}
+	return similarity(normalizeQuery(searchText), normalizeQuery(m.Text()))
+}
+
+// Before returns up to n runes of text immediately preceding the match
+func (m textMatch) Before(n int) string {
+	text := m.segment.String()[:m.start]
+	runes := []rune(text)
+	if len(runes) > n {
+		runes = runes[len(runes)-n:]
+	}
+	return string(runes)
+}
+
+// After returns up to n runes of text immediately following the match
+func (m textMatch) After(n int) string {
+	runes := []rune(m.segment.String()[m.end:])
+	if len(runes) > n {
+		runes = runes[:n]
+	}
+	return string(runes)
+}
+
+// findAll returns every non-overlapping occurrence of searchText in the segment
+func (s *segmentText) findAll(searchText string) []textMatch {
+	if searchText == "" {
+		return nil
+	}
+
+	text := s.String()
+	matches := make([]textMatch, 0)
+	for offset := 0; offset < len(text); {
+		i := strings.Index(text[offset:], searchText)
+		if i < 0 {
+			break
+		}
+
+		start := offset + i
+		end := start + len(searchText)
+		matches = append(matches, textMatch{segment: s, start: start, end: end})
+		offset = end
+	}
+
+	return matches
+}
+
+// findTextMatches returns every occurrence of searchText across all segments of a document
+func findTextMatches(doc *docs.Document, searchText string) []textMatch {
+	searchText = strings.ReplaceAll(searchText, "\v", "\n")
+	if !utf8.ValidString(searchText) {
+		return nil
+	}
+
+	matches := make([]textMatch, 0)
+	for _, segment := range linearizeDocument(doc) {
+		matches = append(matches, segment.findAll(searchText)...)
+	}
+	return matches
+}
+
+// findTextPositions returns the positions of every occurrence of searchText in a document
+func findTextPositions(doc *docs.Document, searchText string) []*TextPosition {
+	matches := findTextMatches(doc, searchText)
+
+	positions := make([]*TextPosition, 0, len(matches))
+	for _, match := range matches {
+		positions = append(positions, match.Position())
+	}
+	return positions
+}
+
+// ErrTextNotFound is returned when no occurrence of a text matches a TextQuery
+var ErrTextNotFound = errors.New("text not found")
+
+// TextQuery describes which occurrence of a text to locate in a document
+type TextQuery struct {
+	Text          string  // Text to locate
+	Occurrence    int     // 1-based occurrence in document order (optional, 0 requires a unique match)
+	ContextBefore string  // Text expected immediately before Text (optional)
+	ContextAfter  string  // Text expected immediately after Text (optional)
+	MinSimilarity float64 // Similarity required for normalized and fuzzy matches (optional, 0 means DefaultMinSimilarity)
+}
+
+// minSimilarity returns the similarity threshold of the query
+func (q *TextQuery) minSimilarity() float64 {
+	if q.MinSimilarity > 0 {
+		return q.MinSimilarity
+	}
+	return DefaultMinSimilarity
+}
+
+// candidateContextLength is the number of runes of context shown for each candidate
+const candidateContextLength = 20
+
+// Candidate is one of several possible matches for an ambiguous TextQuery
+type Candidate struct {
+	Occurrence int
+	Position   *TextPosition
+	Before     string
+	After      string
+}
+
+// AmbiguousTextError is returned when a text occurs several times and the query does not pick one
+type AmbiguousTextError struct {
+	Text       string
+	Candidates []Candidate
+}
+
+func (e *AmbiguousTextError) Error() string {
+	var builder strings.Builder
+	fmt.Fprintf(&builder, "text %q matches %d locations; set an occurrence or surrounding context:", e.Text, len(e.Candidates))
+	for _, c := range e.Candidates {
+		fmt.Fprintf(&builder, "\n  #%d [%d-%d) …%s«%s»%s…", c.Occurrence, c.Position.StartIndex, c.Position.EndIndex, c.Before, c.Position.MatchedText, c.After)
+	}
+	return builder.String()
+}
+
+// matchDocument finds query.Text in a document, trying in order an exact match, a match after
+// normalization (NFKC, whitespace and punctuation folding) and finally the closest fuzzy match.
+// Non-exact matches below the similarity threshold are discarded.
+func matchDocument(doc *docs.Document, query *TextQuery) []textMatch {
+	if matches := findTextMatches(doc, query.Text); len(matches) > 0 {
+		return matches
+	}
+
+	normalized := normalizeQuery(query.Text)
+	if normalized == "" {
+		return nil
+	}
+	segments := linearizeDocument(doc)
+
+	matches := make([]textMatch, 0)
+	for _, segment := range segments {
+		for _, match := range segment.findNormalized(normalized) {
+			if match.Similarity(query.Text) >= query.minSimilarity() {
+				matches = append(matches, match)
+			}
+		}
+	}
+	if len(matches) > 0 {
+		return matches
+	}
+
+	var (
+		best      textMatch
+		bestScore float64
+	)
+	for _, segment := range segments {
+		match, ok := segment.findApproximate(normalized)
+		if !ok {
+			continue
+		}
+		if score := match.Similarity(query.Text); score > bestScore {
+			best, bestScore = match, score
+		}
+	}
+	if bestScore < query.minSimilarity() {
+		return nil
+	}
+
+	return []textMatch{best}
+}
+
+// locateText selects a single occurrence of query.Text in a document and returns its position
+func locateText(doc *docs.Document, query *TextQuery) (*TextPosition, error) {
+	match, err := locateMatch(doc, query)
+	if err != nil {
+		return nil, err
+	}
+
+	position := match.Position()
+	position.Confidence = match.Similarity(query.Text)
+	return position, nil
+}
+
+// locateMatch selects a single occurrence of query.Text in a document.
+// Matches are filtered by the surrounding context, then picked by occurrence.
+func locateMatch(doc *docs.Document, query *TextQuery) (textMatch, error) {
+	matches := matchDocument(doc, query)
+	if len(matches) == 0 {
+		return textMatch{}, fmt.Errorf("%w: %s", ErrTextNotFound, query.Text)
+	}
+
+	candidates := make([]Candidate, 0, len(matches))
+	filtered := make([]textMatch, 0, len(matches))
+	for i, match := range matches {
+		if query.ContextBefore != "" && !strings.HasSuffix(normalizedString(match.segment.String()[:match.start]), normalizedString(query.ContextBefore)) {
+			continue
+		}
+		if query.ContextAfter != "" && !strings.HasPrefix(normalizedString(match.segment.String()[match.end:]), normalizedString(query.ContextAfter)) {
+			continue
+		}
+
+		position := match.Position()
+		position.Confidence = match.Similarity(query.Text)
+
+		candidates = append(candidates, Candidate{
+			Occurrence: i + 1,
+			Position:   position,
+			Before:     match.Before(candidateContextLength),
+			After:      match.After(candidateContextLength),
+		})
+		filtered = append(filtered, match)
+	}
+
+	if len(candidates) == 0 {
+		return textMatch{}, fmt.Errorf("%w: found %d times but none matches the given context: %s", ErrTextNotFound, len(matches), query.Text)
+	}
+
+	if query.Occurrence > 0 {
+		for i, candidate := range candidates {
+			if candidate.Occurrence == query.Occurrence {
+				return filtered[i], nil
+			}
+		}
+		return textMatch{}, fmt.Errorf("%w: occurrence %d not found among %d matches: %s", ErrTextNotFound, query.Occurrence, len(matches), query.Text)
+	}
+
+	if len(candidates) > 1 {
+		return textMatch{}, &AmbiguousTextError{Text: query.Text, Candidates: candidates}
+	}
+
+	return filtered[0], nil
+}
@@ -0,0 +1,221 @@
+package comment
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"google.golang.org/api/docs/v1"
+)
+
+// ignoreMatchDetails compares text positions by range and segment only
+var ignoreMatchDetails = cmpopts.IgnoreFields(TextPosition{}, "MatchedText", "Confidence")
+
+// textParagraph builds a paragraph element with one text run per content string
+func textParagraph(start int64, contents ...string) *docs.StructuralElement {
+	element := &docs.StructuralElement{StartIndex: start, Paragraph: &docs.Paragraph{}}
+
+	index := start
+	for _, content := range contents {
+		length := int64(len(utf16Encode(content)))
+		element.Paragraph.Elements = append(element.Paragraph.Elements, &docs.ParagraphElement{
+			StartIndex: index,
+			EndIndex:   index + length,
+			TextRun:    &docs.TextRun{Content: content},
+		})
+		index += length
+	}
+	element.EndIndex = index
+
+	return element
+}
+
+// utf16Encode returns the UTF-16 code units of s
+func utf16Encode(s string) []uint16 {
+	units := make([]uint16, 0, len(s))
+	for _, r := range s {
+		if r >= 0x10000 {
+			units = append(units, 0, 0)
+			continue
+		}
+		units = append(units, uint16(r))
+	}
+	return units
+}
+
+func TestFindTextPositions(t *testing.T) {
+	doc := &docs.Document{
+		Body: &docs.Body{
+			Content: []*docs.StructuralElement{
+				{EndIndex: 1, SectionBreak: &docs.SectionBreak{}},
+				// "[Design Doc] " is formatted separately from the title text
+				textParagraph(1, "[Design Doc] ", "テストデザイン", "ドッグ\n"),
+				// 😀 takes two UTF-16 code units
+				textParagraph(24, "😀 絵文字の後\n"),
+				{
+					StartIndex: 33,
+					EndIndex:   45,
+					Table: &docs.Table{
+						TableRows: []*docs.TableRow{
+							{
+								TableCells: []*docs.TableCell{
+									{Content: []*docs.StructuralElement{textParagraph(35, "セルの中\n")}},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		Headers: map[string]docs.Header{
+			"kix.header": {Content: []*docs.StructuralElement{textParagraph(0, "ヘッダー\n")}},
+		},
+		Footnotes: map[string]docs.Footnote{
+			"kix.footnote": {Content: []*docs.StructuralElement{textParagraph(0, "脚注のテキスト\n")}},
+		},
+	}
+
+	tests := []struct {
+		name   string
+		search string
+		want   []*TextPosition
+	}{
+		{
+			name:   "across formatting runs",
+			search: "Doc] テストデザインドッグ",
+			want:   []*TextPosition{{StartIndex: 9, EndIndex: 24}},
+		},
+		{
+			name:   "after surrogate pair",
+			search: "絵文字",
+			want:   []*TextPosition{{StartIndex: 27, EndIndex: 30}},
+		},
+		{
+			name:   "inside table",
+			search: "セル",
+			want:   []*TextPosition{{StartIndex: 35, EndIndex: 37}},
+		},
+		{
+			name:   "in header",
+			search: "ヘッダー",
+			want:   []*TextPosition{{StartIndex: 0, EndIndex: 4, SegmentID: "kix.header"}},
+		},
+		{
+			name:   "in footnote",
+			search: "テキスト",
+			want:   []*TextPosition{{StartIndex: 3, EndIndex: 7, SegmentID: "kix.footnote"}},
+		},
+		{
+			name:   "multiple occurrences",
+			search: "の",
+			want: []*TextPosition{
+				{StartIndex: 30, EndIndex: 31},
+				{StartIndex: 37, EndIndex: 38},
+				{StartIndex: 2, EndIndex: 3, SegmentID: "kix.footnote"},
+			},
+		},
+		{
+			name:   "not found",
+			search: "存在しない",
+			want:   []*TextPosition{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := findTextPositions(doc, tt.search)
+			if diff := cmp.Diff(tt.want, got, ignoreMatchDetails); diff != "" {
+				t.Errorf("findTextPositions() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestLocateText(t *testing.T) {
+	doc := &docs.Document{
+		Body: &docs.Body{
+			Content: []*docs.StructuralElement{
+				textParagraph(1, "設計A: テスト\n"),
+				textParagraph(10, "設計B: テスト\n"),
+			},
+		},
+	}
+
+	tests := []struct {
+		name          string
+		query         *TextQuery
+		want          *TextPosition
+		wantAmbiguous bool
+		wantErr       bool
+	}{
+		{
+			name:  "unique match",
+			query: &TextQuery{Text: "設計A"},
+			want:  &TextPosition{StartIndex: 1, EndIndex: 4},
+		},
+		{
+			name:          "ambiguous without hint",
+			query:         &TextQuery{Text: "テスト"},
+			wantAmbiguous: true,
+		},
+		{
+			name:  "by occurrence",
+			query: &TextQuery{Text: "テスト", Occurrence: 2},
+			want:  &TextPosition{StartIndex: 15, EndIndex: 18},
+		},
+		{
+			name:  "by context before",
+			query: &TextQuery{Text: "テスト", ContextBefore: "A: "},
+			want:  &TextPosition{StartIndex: 6, EndIndex: 9},
+		},
+		{
+			name:  "by context after",
+			query: &TextQuery{Text: "設計", ContextAfter: "B"},
+			want:  &TextPosition{StartIndex: 10, EndIndex: 12},
+		},
+		{
+			name:    "occurrence out of range",
+			query:   &TextQuery{Text: "テスト", Occurrence: 3},
+			wantErr: true,
+		},
+		{
+			name:    "context does not match",
+			query:   &TextQuery{Text: "テスト", ContextBefore: "C: "},
+			wantErr: true,
+		},
+		{
+			name:    "not found",
+			query:   &TextQuery{Text: "存在しない"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := locateText(doc, tt.query)
+
+			var ambiguous *AmbiguousTextError
+			if errors.As(err, &ambiguous) != tt.wantAmbiguous {
+				t.Fatalf("locateText() error = %v, wantAmbiguous %v", err, tt.wantAmbiguous)
+			}
+			if tt.wantAmbiguous {
+				if len(ambiguous.Candidates) != 2 {
+					t.Errorf("candidates = %d, want 2", len(ambiguous.Candidates))
+				}
+				if !strings.Contains(err.Error(), "#2 [15-18)") {
+					t.Errorf("error should list candidates, got %q", err.Error())
+				}
+				return
+			}
+
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("locateText() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if diff := cmp.Diff(tt.want, got, ignoreMatchDetails); diff != "" {
+				t.Errorf("locateText() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
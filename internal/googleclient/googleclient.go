@@ -0,0 +1,149 @@
+// Package googleclient builds the Google API clients of a program from its configuration, so every
+// entry point authenticates, retries and caches documents the same way.
+package googleclient
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/takeuchi-shogo/google-doc-review/config"
+	"github.com/takeuchi-shogo/google-doc-review/internal/authmanager"
+	"github.com/takeuchi-shogo/google-doc-review/internal/comment"
+	"github.com/takeuchi-shogo/google-doc-review/internal/retry"
+	"github.com/takeuchi-shogo/google-doc-review/internal/review"
+	"google.golang.org/api/docs/v1"
+	"google.golang.org/api/drive/v3"
+	"google.golang.org/api/option"
+)
+
+// DefaultUserAgent is sent with every Docs and Drive request
+const DefaultUserAgent = "google-doc-review/0.0.1"
+
+// Clients is the set of Google API clients shared by the components of a program
+type Clients struct {
+	HTTPClient *http.Client // Authorized client that retries quota and server errors
+	Docs       *docs.Service
+	Drive      *drive.Service
+	Documents  *review.DocumentCache // Document cache shared by the fetcher and the comment manager
+
+	config *config.Config
+	retry  retry.Config
+}
+
+// options holds the settings of New
+type options struct {
+	httpClient    *http.Client
+	authenticator authmanager.Authenticator
+	baseURL       string
+	userAgent     string
+}
+
+// Option configures New
+type Option func(*options)
+
+// WithHTTPClient uses an already authorized client instead of running the OAuth flow
+func WithHTTPClient(client *http.Client) Option {
+	return func(o *options) {
+		o.httpClient = client
+	}
+}
+
+// WithAuthenticator replaces the browser based OAuth flow
+func WithAuthenticator(authenticator authmanager.Authenticator) Option {
+	return func(o *options) {
+		o.authenticator = authenticator
+	}
+}
+
+// WithBaseURL sends Docs and Drive requests to baseURL instead of the Google endpoints, e.g. an httptest server
+func WithBaseURL(baseURL string) Option {
+	return func(o *options) {
+		o.baseURL = strings.TrimSuffix(baseURL, "/")
+	}
+}
+
+// WithUserAgent replaces DefaultUserAgent
+func WithUserAgent(userAgent string) Option {
+	return func(o *options) {
+		o.userAgent = userAgent
+	}
+}
+
+// New authenticates and builds the clients configured by cfg
+func New(ctx context.Context, cfg *config.Config, opts ...Option) (*Clients, error) {
+	o := &options{
+		authenticator: &authmanager.BrowserAuthenticator{},
+		userAgent:     DefaultUserAgent,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	client := o.httpClient
+	if client == nil {
+		authMgr := authmanager.NewWithConfig(cfg.Google.ClientID, cfg.Google.ClientSecret, o.authenticator)
+
+		var err error
+		client, err = authMgr.GetOrAuthenticateClient(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get authenticated client: %w", err)
+		}
+	}
+
+	// Retry quota and server errors of every request
+	retryConfig := retry.Config{
+		MaxAttempts: cfg.Retry.MaxAttempts,
+		BaseDelay:   cfg.Retry.BaseDelay,
+		MaxDelay:    cfg.Retry.MaxDelay,
+	}
+	client = retry.NewClient(client, retryConfig)
+
+	docsOptions := []option.ClientOption{option.WithHTTPClient(client)}
+	driveOptions := []option.ClientOption{option.WithHTTPClient(client)}
+	if o.baseURL != "" {
+		docsOptions = append(docsOptions, option.WithEndpoint(o.baseURL+"/"))
+		driveOptions = append(driveOptions, option.WithEndpoint(o.baseURL+"/drive/v3/"))
+	}
+
+	docsService, err := docs.NewService(ctx, docsOptions...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Docs service: %w", err)
+	}
+
+	driveService, err := drive.NewService(ctx, driveOptions...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Drive service: %w", err)
+	}
+
+	// option.WithUserAgent is ignored together with option.WithHTTPClient, so the services are set directly
+	docsService.UserAgent = o.userAgent
+	driveService.UserAgent = o.userAgent
+
+	return &Clients{
+		HTTPClient: client,
+		Docs:       docsService,
+		Drive:      driveService,
+		Documents:  review.NewDocumentCache(docsService, 0),
+		config:     cfg,
+		retry:      retryConfig,
+	}, nil
+}
+
+// Fetcher returns a GoogleDocFetcher using the shared services and document cache
+func (c *Clients) Fetcher(opts ...review.FetcherOption) *review.GoogleDocFetcher {
+	opts = append([]review.FetcherOption{review.WithDocumentCache(c.Documents)}, opts...)
+	return review.NewGoogleDocFetcherWithServices(c.HTTPClient, c.Docs, c.Drive, opts...)
+}
+
+// CommentManager returns a CommentManager using the shared services and document cache, with the
+// anchor strategy and retry settings of the configuration. opts are applied after them.
+func (c *Clients) CommentManager(opts ...comment.Option) (*comment.CommentManager, error) {
+	opts = append([]comment.Option{
+		comment.WithDefaultAnchorStrategy(c.config.Comment.AnchorStrategy),
+		comment.WithRetry(c.retry),
+		comment.WithDocumentCache(c.Documents),
+	}, opts...)
+	return comment.NewCommentManagerWithServices(c.HTTPClient, c.Drive, c.Docs, opts...)
+}
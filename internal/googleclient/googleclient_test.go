@@ -0,0 +1,227 @@
+package googleclient
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/takeuchi-shogo/google-doc-review/config"
+	"github.com/takeuchi-shogo/google-doc-review/internal/comment"
+	"google.golang.org/api/docs/v1"
+)
+
+// googleServer serves one Docs document and the Drive comments of it, recording the requests
+type googleServer struct {
+	mu          sync.Mutex
+	unavailable int // Number of document requests answered with 503 before succeeding
+	fetches     int
+	userAgents  []string
+}
+
+func (s *googleServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.userAgents = append(s.userAgents, r.Header.Get("User-Agent"))
+
+	w.Header().Set("Content-Type", "application/json")
+	switch {
+	case r.Method == http.MethodGet && r.URL.Path == "/v1/documents/doc-1" && r.URL.Query().Get("fields") == "revisionId":
+		json.NewEncoder(w).Encode(&docs.Document{RevisionId: "rev-1"})
+	case r.Method == http.MethodGet && r.URL.Path == "/v1/documents/doc-1":
+		s.fetches++
+		if s.unavailable > 0 {
+			s.unavailable--
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		json.NewEncoder(w).Encode(&docs.Document{
+			DocumentId: "doc-1",
+			Title:      "Design Doc",
+			RevisionId: "rev-1",
+			Body: &docs.Body{Content: []*docs.StructuralElement{{
+				StartIndex: 1,
+				EndIndex:   13,
+				Paragraph: &docs.Paragraph{Elements: []*docs.ParagraphElement{{
+					StartIndex: 1,
+					EndIndex:   13,
+					TextRun:    &docs.TextRun{Content: "alpha beta.\n"},
+				}}},
+			}}},
+		})
+	case r.Method == http.MethodGet && r.URL.Path == "/drive/v3/files/doc-1/comments":
+		json.NewEncoder(w).Encode(map[string]any{"comments": []any{}})
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// newTestClients creates Clients sending every request to a googleServer
+func newTestClients(t *testing.T, backend *googleServer, cfg *config.Config, opts ...Option) *Clients {
+	t.Helper()
+
+	server := httptest.NewServer(backend)
+	t.Cleanup(server.Close)
+
+	if cfg == nil {
+		cfg = &config.Config{}
+	}
+	opts = append([]Option{WithHTTPClient(server.Client()), WithBaseURL(server.URL)}, opts...)
+
+	clients, err := New(context.Background(), cfg, opts...)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	return clients
+}
+
+func TestNew_UserAgent(t *testing.T) {
+	tests := []struct {
+		name string
+		opts []Option
+		want string
+	}{
+		{
+			name: "default user agent",
+			want: DefaultUserAgent,
+		},
+		{
+			name: "custom user agent",
+			opts: []Option{WithUserAgent("review-bot/1.0")},
+			want: "review-bot/1.0",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			backend := &googleServer{}
+			clients := newTestClients(t, backend, nil, tt.opts...)
+			ctx := context.Background()
+
+			if _, err := clients.Docs.Documents.Get("doc-1").Context(ctx).Do(); err != nil {
+				t.Fatalf("Documents.Get() error = %v", err)
+			}
+			if _, err := clients.Drive.Comments.List("doc-1").Fields("comments").Context(ctx).Do(); err != nil {
+				t.Fatalf("Comments.List() error = %v", err)
+			}
+
+			if len(backend.userAgents) != 2 {
+				t.Fatalf("got %d requests, want 2", len(backend.userAgents))
+			}
+			for _, userAgent := range backend.userAgents {
+				if !strings.Contains(userAgent, tt.want) {
+					t.Errorf("User-Agent = %q, want it to contain %q", userAgent, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestNew_Retry(t *testing.T) {
+	tests := []struct {
+		name        string
+		maxAttempts int
+		wantErr     bool
+		wantFetches int
+	}{
+		{
+			name:        "server errors are retried",
+			maxAttempts: 3,
+			wantFetches: 3,
+		},
+		{
+			name:        "retries are limited by the configuration",
+			maxAttempts: 2,
+			wantErr:     true,
+			wantFetches: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			backend := &googleServer{unavailable: 2}
+			cfg := &config.Config{Retry: config.RetryConfig{
+				MaxAttempts: tt.maxAttempts,
+				BaseDelay:   time.Millisecond,
+				MaxDelay:    time.Millisecond,
+			}}
+			clients := newTestClients(t, backend, cfg)
+
+			_, err := clients.Fetcher().FetchDocumentByID(context.Background(), "doc-1", nil)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("FetchDocumentByID() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if backend.fetches != tt.wantFetches {
+				t.Errorf("fetches = %d, want %d", backend.fetches, tt.wantFetches)
+			}
+		})
+	}
+}
+
+func TestClients_SharedDocumentCache(t *testing.T) {
+	backend := &googleServer{}
+	clients := newTestClients(t, backend, nil)
+	ctx := context.Background()
+
+	doc, err := clients.Fetcher().FetchDocumentByID(ctx, "doc-1", nil)
+	if err != nil {
+		t.Fatalf("FetchDocumentByID() error = %v", err)
+	}
+	if doc.Title != "Design Doc" {
+		t.Errorf("Title = %q, want %q", doc.Title, "Design Doc")
+	}
+
+	commentMgr, err := clients.CommentManager()
+	if err != nil {
+		t.Fatalf("CommentManager() error = %v", err)
+	}
+	position, err := commentMgr.LocateText(ctx, "doc-1", &comment.TextQuery{Text: "beta"})
+	if err != nil {
+		t.Fatalf("LocateText() error = %v", err)
+	}
+	if position.MatchedText != "beta" {
+		t.Errorf("MatchedText = %q, want %q", position.MatchedText, "beta")
+	}
+
+	if backend.fetches != 1 {
+		t.Errorf("document fetched %d times, want 1", backend.fetches)
+	}
+}
+
+func TestClients_CommentManager(t *testing.T) {
+	tests := []struct {
+		name           string
+		anchorStrategy string
+		wantErr        bool
+	}{
+		{
+			name: "default anchor strategy",
+		},
+		{
+			name:           "configured anchor strategy",
+			anchorStrategy: comment.AnchorStrategyQuotedText,
+		},
+		{
+			name:           "unknown anchor strategy",
+			anchorStrategy: "unknown",
+			wantErr:        true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.Config{Comment: config.CommentConfig{AnchorStrategy: tt.anchorStrategy}}
+			clients := newTestClients(t, &googleServer{}, cfg)
+
+			_, err := clients.CommentManager()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("CommentManager() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -8,9 +8,10 @@ import (
 	"github.com/mark3labs/mcp-go/server"
 
 	"github.com/takeuchi-shogo/google-doc-review/config"
-	"github.com/takeuchi-shogo/google-doc-review/internal/authmanager"
 	"github.com/takeuchi-shogo/google-doc-review/internal/comment"
+	"github.com/takeuchi-shogo/google-doc-review/internal/googleclient"
 	"github.com/takeuchi-shogo/google-doc-review/internal/review"
+	"github.com/takeuchi-shogo/google-doc-review/internal/session"
 )
 
 func Run() error {
@@ -29,26 +30,26 @@ func Run() error {
 		server.WithToolCapabilities(true), // ツール機能を有効化
 	)
 
-	// 認証してHTTPクライアントを取得
-	authMgr := authmanager.NewWithConfig(
-		cfg.Google.ClientID,
-		cfg.Google.ClientSecret,
-		&authmanager.BrowserAuthenticator{},
-	)
-	client, err := authMgr.GetOrAuthenticateClient(ctx)
+	// 認証し、Google APIのクライアント一式を作成
+	clients, err := googleclient.New(ctx, cfg)
 	if err != nil {
-		return fmt.Errorf("failed to get authenticated client: %w", err)
+		return fmt.Errorf("failed to create Google clients: %w", err)
 	}
 
-	// GoogleDocFetcherを作成
-	fetcher := review.NewGoogleDocFetcher(client)
-
-	// CommentManagerを作成
-	commentMgr, err := comment.NewCommentManager(client)
+	// GoogleDocFetcherとCommentManagerを作成（サービスとドキュメントのキャッシュを共有する）
+	fetcher := clients.Fetcher()
+	commentMgr, err := clients.CommentManager()
 	if err != nil {
 		return fmt.Errorf("failed to create comment manager: %w", err)
 	}
 
+	// レビューセッションの保存先を用意
+	sessionDir, err := session.DefaultDir()
+	if err != nil {
+		return fmt.Errorf("failed to get session directory: %w", err)
+	}
+	reconciler := session.NewReconciler(commentMgr, session.NewStore(sessionDir))
+
 	// ツールを登録
 	// 1. fetch_google_doc - ドキュメント取得
 	tool := mcp.NewTool("fetch_google_doc",
@@ -57,6 +58,14 @@ func Run() error {
 			mcp.Required(),
 			mcp.Description("The Google Docs URL to fetch"),
 		),
+		mcp.WithString("format",
+			mcp.Description("Optional: Output format (default: text). 'numbered' prefixes each line with the line number used by create_anchored_comment and its Docs index range"),
+			mcp.Enum(string(review.FormatText), string(review.FormatMarkdown), string(review.FormatJSON), string(review.FormatNumbered)),
+		),
+		mcp.WithString("suggestions",
+			mcp.Description("Optional: How pending suggestions are shown (default: Docs default). 'accepted' shows the document as it reads after the suggestions land; its line numbers cannot be used for anchors"),
+			mcp.Enum(string(review.SuggestionsViewInline), string(review.SuggestionsViewAccepted), string(review.SuggestionsViewRejected)),
+		),
 	)
 
 	s.AddTool(tool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
@@ -66,14 +75,29 @@ func Run() error {
 			return mcp.NewToolResultError(err.Error()), nil
 		}
 
+		format := review.Format(request.GetString("format", string(review.FormatText)))
+		suggestionsView := review.SuggestionsView(request.GetString("suggestions", ""))
+
 		// ドキュメントを取得
-		doc, err := fetcher.FetchDocument(ctx, url)
+		doc, err := fetcher.FetchDocument(ctx, url, &review.FetchOptions{SuggestionsView: suggestionsView})
 		if err != nil {
 			return mcp.NewToolResultError(fmt.Sprintf("failed to fetch document: %v", err)), nil
 		}
 
+		// 指定されたフォーマットで出力
+		content, err := doc.Render(format)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("failed to render document: %v", err)), nil
+		}
+
 		// 結果を返す
-		result := fmt.Sprintf("Title: %s\n\nContent:\n%s", doc.Title, doc.Content)
+		if format == review.FormatJSON {
+			return mcp.NewToolResultText(content), nil
+		}
+		result := fmt.Sprintf("Title: %s\n\nContent:\n%s", doc.Title, content)
+		if len(doc.Suggestions) > 0 {
+			result += fmt.Sprintf("\n\nPending suggestions (%d):\n%s", len(doc.Suggestions), review.RenderSuggestions(doc.Suggestions))
+		}
 		return mcp.NewToolResultText(result), nil
 	})
 
@@ -91,6 +115,28 @@ func Run() error {
 		mcp.WithString("quoted_text",
 			mcp.Description("Optional: Text to quote in the comment"),
 		),
+		mcp.WithNumber("occurrence",
+			mcp.Description("Optional: Which occurrence of quoted_text to anchor to (1-based), when it appears more than once"),
+		),
+		mcp.WithString("context_before",
+			mcp.Description("Optional: Text immediately before quoted_text, to pick the right occurrence"),
+		),
+		mcp.WithString("context_after",
+			mcp.Description("Optional: Text immediately after quoted_text, to pick the right occurrence"),
+		),
+		mcp.WithNumber("min_similarity",
+			mcp.Description("Optional: Similarity (0-1) required when quoted_text only matches approximately (default: 0.8)"),
+		),
+		mcp.WithBoolean("strict",
+			mcp.Description("Optional: Do not post the comment if quoted_text cannot be anchored (default: false)"),
+		),
+		mcp.WithString("anchor_strategy",
+			mcp.Description("Optional: How to anchor the comment (default: server setting)"),
+			mcp.Enum(comment.AnchorStrategyPosition, comment.AnchorStrategyLine, comment.AnchorStrategyQuotedText, comment.AnchorStrategyNamedRange),
+		),
+		mcp.WithNumber("line_number",
+			mcp.Description("Optional: Line number for the line anchor strategy, as shown by fetch_google_doc with format=numbered"),
+		),
 	)
 
 	s.AddTool(createCommentTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
@@ -115,9 +161,17 @@ func Run() error {
 
 		// コメントを作成
 		req := &comment.CommentRequest{
-			FileID:     docID,
-			Content:    content,
-			QuotedText: quotedText,
+			FileID:        docID,
+			Content:       content,
+			QuotedText:    quotedText,
+			Occurrence:    request.GetInt("occurrence", 0),
+			ContextBefore: request.GetString("context_before", ""),
+			ContextAfter:  request.GetString("context_after", ""),
+			MinSimilarity: request.GetFloat("min_similarity", 0),
+			Strict:        request.GetBool("strict", false),
+
+			AnchorStrategy: request.GetString("anchor_strategy", ""),
+			LineNumber:     request.GetInt("line_number", 0),
 		}
 
 		resp, err := commentMgr.CreateComment(ctx, req)
@@ -128,6 +182,16 @@ func Run() error {
 		// 結果を返す
 		result := fmt.Sprintf("Comment created successfully!\nComment ID: %s\nContent: %s\nCreated at: %s",
 			resp.CommentID, resp.Content, resp.CreatedAt)
+		result += fmt.Sprintf("\nAnchor status: %s", resp.AnchorStatus)
+		if resp.AnchorReason != "" {
+			result += fmt.Sprintf("\nAnchor reason: %s", resp.AnchorReason)
+		}
+		if resp.MatchedText != "" {
+			result += fmt.Sprintf("\nMatched text: %s\nConfidence: %.2f", resp.MatchedText, resp.Confidence)
+		}
+		if resp.NamedRange != "" {
+			result += fmt.Sprintf("\nNamed range: %s", resp.NamedRange)
+		}
 		return mcp.NewToolResultText(result), nil
 	})
 
@@ -144,7 +208,7 @@ func Run() error {
 		),
 		mcp.WithNumber("line_number",
 			mcp.Required(),
-			mcp.Description("The line number to anchor the comment to"),
+			mcp.Description("The line number to anchor the comment to, as shown by fetch_google_doc with format=numbered"),
 		),
 		mcp.WithString("quoted_text",
 			mcp.Description("Optional: Text to quote in the comment"),
@@ -200,6 +264,721 @@ func Run() error {
 		return mcp.NewToolResultText(result), nil
 	})
 
+	// 4. list_comments - コメント一覧取得
+	listCommentsTool := mcp.NewTool("list_comments",
+		mcp.WithDescription("List existing comments on a Google Doc, including replies and resolved state"),
+		mcp.WithString("url",
+			mcp.Required(),
+			mcp.Description("The Google Docs URL"),
+		),
+	)
+
+	s.AddTool(listCommentsTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		// パラメータを取得
+		url, err := request.RequireString("url")
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		// URLからドキュメントIDを抽出
+		docID, err := review.ExtractDocumentID(url)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("invalid URL: %v", err)), nil
+		}
+
+		// コメント一覧を取得
+		comments, err := commentMgr.ListComments(ctx, docID, nil)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("failed to list comments: %v", err)), nil
+		}
+
+		// 結果をJSONで返す
+		result, err := mcp.NewToolResultJSON(map[string]any{"comments": comments})
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("failed to encode comments: %v", err)), nil
+		}
+		return result, nil
+	})
+
+	// 5. reply_to_comment - コメントへの返信
+	replyToCommentTool := mcp.NewTool("reply_to_comment",
+		mcp.WithDescription("Reply to an existing comment thread on a Google Doc"),
+		mcp.WithString("url",
+			mcp.Required(),
+			mcp.Description("The Google Docs URL"),
+		),
+		mcp.WithString("comment_id",
+			mcp.Required(),
+			mcp.Description("The ID of the comment to reply to"),
+		),
+		mcp.WithString("content",
+			mcp.Required(),
+			mcp.Description("The reply content"),
+		),
+	)
+
+	s.AddTool(replyToCommentTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		// パラメータを取得
+		url, err := request.RequireString("url")
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		commentID, err := request.RequireString("comment_id")
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		content, err := request.RequireString("content")
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		// URLからドキュメントIDを抽出
+		docID, err := review.ExtractDocumentID(url)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("invalid URL: %v", err)), nil
+		}
+
+		// 返信を作成
+		reply, err := commentMgr.ReplyToComment(ctx, docID, commentID, content)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("failed to reply to comment: %v", err)), nil
+		}
+
+		// 結果を返す
+		result := fmt.Sprintf("Reply created successfully!\nComment ID: %s\nReply ID: %s\nContent: %s\nCreated at: %s",
+			commentID, reply.ID, reply.Content, reply.CreatedAt)
+		return mcp.NewToolResultText(result), nil
+	})
+
+	// 6. resolve_comment - コメントの解決
+	resolveCommentTool := mcp.NewTool("resolve_comment",
+		mcp.WithDescription("Resolve a comment thread on a Google Doc"),
+		mcp.WithString("url",
+			mcp.Required(),
+			mcp.Description("The Google Docs URL"),
+		),
+		mcp.WithString("comment_id",
+			mcp.Required(),
+			mcp.Description("The ID of the comment to resolve"),
+		),
+		mcp.WithString("content",
+			mcp.Description("Optional: Closing message posted with the resolution"),
+		),
+	)
+
+	s.AddTool(resolveCommentTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		// パラメータを取得
+		url, err := request.RequireString("url")
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		commentID, err := request.RequireString("comment_id")
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		content := request.GetString("content", "")
+
+		// URLからドキュメントIDを抽出
+		docID, err := review.ExtractDocumentID(url)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("invalid URL: %v", err)), nil
+		}
+
+		// コメントを解決
+		reply, err := commentMgr.ResolveComment(ctx, docID, commentID, content)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("failed to resolve comment: %v", err)), nil
+		}
+
+		// 結果を返す
+		result := fmt.Sprintf("Comment resolved successfully!\nComment ID: %s\nReply ID: %s\nCreated at: %s",
+			commentID, reply.ID, reply.CreatedAt)
+		return mcp.NewToolResultText(result), nil
+	})
+
+	// 7. reopen_comment - コメントの再オープン
+	reopenCommentTool := mcp.NewTool("reopen_comment",
+		mcp.WithDescription("Reopen a resolved comment thread on a Google Doc"),
+		mcp.WithString("url",
+			mcp.Required(),
+			mcp.Description("The Google Docs URL"),
+		),
+		mcp.WithString("comment_id",
+			mcp.Required(),
+			mcp.Description("The ID of the comment to reopen"),
+		),
+		mcp.WithString("content",
+			mcp.Description("Optional: Message explaining why the thread is reopened"),
+		),
+	)
+
+	s.AddTool(reopenCommentTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		// パラメータを取得
+		url, err := request.RequireString("url")
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		commentID, err := request.RequireString("comment_id")
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		content := request.GetString("content", "")
+
+		// URLからドキュメントIDを抽出
+		docID, err := review.ExtractDocumentID(url)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("invalid URL: %v", err)), nil
+		}
+
+		// コメントを再オープン
+		reply, err := commentMgr.ReopenComment(ctx, docID, commentID, content)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("failed to reopen comment: %v", err)), nil
+		}
+
+		// 結果を返す
+		result := fmt.Sprintf("Comment reopened successfully!\nComment ID: %s\nReply ID: %s\nCreated at: %s",
+			commentID, reply.ID, reply.CreatedAt)
+		return mcp.NewToolResultText(result), nil
+	})
+
+	// 8. update_comment - コメントの編集
+	updateCommentTool := mcp.NewTool("update_comment",
+		mcp.WithDescription("Edit the content of an existing comment on a Google Doc"),
+		mcp.WithString("url",
+			mcp.Required(),
+			mcp.Description("The Google Docs URL"),
+		),
+		mcp.WithString("comment_id",
+			mcp.Required(),
+			mcp.Description("The ID of the comment to edit"),
+		),
+		mcp.WithString("content",
+			mcp.Required(),
+			mcp.Description("The new comment content"),
+		),
+	)
+
+	s.AddTool(updateCommentTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		// パラメータを取得
+		url, err := request.RequireString("url")
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		commentID, err := request.RequireString("comment_id")
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		content, err := request.RequireString("content")
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		// URLからドキュメントIDを抽出
+		docID, err := review.ExtractDocumentID(url)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("invalid URL: %v", err)), nil
+		}
+
+		// コメントを更新
+		updated, err := commentMgr.UpdateComment(ctx, docID, commentID, content)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("failed to update comment: %v", err)), nil
+		}
+
+		// 結果を返す
+		result := fmt.Sprintf("Comment updated successfully!\nComment ID: %s\nContent: %s\nModified at: %s",
+			updated.ID, updated.Content, updated.ModifiedAt)
+		return mcp.NewToolResultText(result), nil
+	})
+
+	// 9. post_review_issues - レビュー指摘の一括投稿
+	postReviewIssuesTool := mcp.NewTool("post_review_issues",
+		mcp.WithDescription("Post a batch of review issues on a Google Doc as formatted comments"),
+		mcp.WithString("url",
+			mcp.Required(),
+			mcp.Description("The Google Docs URL"),
+		),
+		mcp.WithArray("issues",
+			mcp.Required(),
+			mcp.Description("The issues to post, one comment per issue"),
+			mcp.Items(map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"type": map[string]any{
+						"type": "string",
+						"enum": []string{
+							string(comment.IssueTypeGrammar),
+							string(comment.IssueTypeClarity),
+							string(comment.IssueTypeStructure),
+							string(comment.IssueTypeMissing),
+							string(comment.IssueTypeInconsistent),
+						},
+					},
+					"severity": map[string]any{
+						"type": "string",
+						"enum": []string{
+							string(comment.SeverityCritical),
+							string(comment.SeverityWarning),
+							string(comment.SeverityInfo),
+						},
+					},
+					"quoted_text":    map[string]any{"type": "string", "description": "Text in the document the issue refers to"},
+					"occurrence":     map[string]any{"type": "number", "description": "Which occurrence of quoted_text is meant (1-based)"},
+					"context_before": map[string]any{"type": "string", "description": "Text immediately before quoted_text"},
+					"context_after":  map[string]any{"type": "string", "description": "Text immediately after quoted_text"},
+					"description":    map[string]any{"type": "string", "description": "What is wrong"},
+					"suggestion":     map[string]any{"type": "string", "description": "How to fix it"},
+					"replacement":    map[string]any{"type": "string", "description": "Exact text to replace quoted_text with, so the fix can be applied with apply_suggestions"},
+				},
+				"required": []string{"type", "severity", "description"},
+			}),
+		),
+		mcp.WithBoolean("reconcile",
+			mcp.Description("Optional: Track issues in the local review session of the document. Issues already posted are skipped, issues no longer reported are resolved with a reply, so issues must be the complete result of the review (default: false)"),
+		),
+	)
+
+	s.AddTool(postReviewIssuesTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		// パラメータを取得
+		url, err := request.RequireString("url")
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		var args struct {
+			Issues []comment.Issue `json:"issues"`
+		}
+		if err := request.BindArguments(&args); err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("invalid issues: %v", err)), nil
+		}
+		if len(args.Issues) == 0 {
+			return mcp.NewToolResultError("issues must contain at least one issue"), nil
+		}
+
+		// URLからドキュメントIDを抽出
+		docID, err := review.ExtractDocumentID(url)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("invalid URL: %v", err)), nil
+		}
+
+		// 前回のレビューと突き合わせて投稿
+		if request.GetBool("reconcile", false) {
+			// セッションの保存に失敗しても、投稿済みのコメントは結果として返す
+			reconciled, saveErr := reconciler.Reconcile(ctx, docID, args.Issues)
+			if reconciled == nil {
+				return mcp.NewToolResultError(fmt.Sprintf("failed to reconcile issues: %v", saveErr)), nil
+			}
+
+			type outcomeResult struct {
+				Action       string `json:"action"`
+				Fingerprint  string `json:"fingerprint"`
+				CommentID    string `json:"comment_id,omitempty"`
+				Description  string `json:"description"`
+				AnchorStatus string `json:"anchor_status,omitempty"`
+				Error        string `json:"error,omitempty"`
+			}
+
+			results := make([]outcomeResult, 0, len(reconciled.Outcomes))
+			for _, outcome := range reconciled.Outcomes {
+				r := outcomeResult{
+					Action:      string(outcome.Action),
+					Fingerprint: outcome.Fingerprint,
+					CommentID:   outcome.CommentID,
+					Description: outcome.Issue.Description,
+				}
+				if outcome.Comment != nil {
+					r.AnchorStatus = string(outcome.Comment.AnchorStatus)
+				}
+				if outcome.Err != nil {
+					r.Error = outcome.Err.Error()
+				}
+				results = append(results, r)
+			}
+
+			response := map[string]any{
+				"revision": reconciled.Revision,
+				"posted":   reconciled.Count(session.ActionPosted),
+				"skipped":  reconciled.Count(session.ActionSkipped),
+				"resolved": reconciled.Count(session.ActionResolved),
+				"failed":   reconciled.Count(session.ActionFailed),
+				"results":  results,
+			}
+			if saveErr != nil {
+				response["save_error"] = saveErr.Error()
+			}
+
+			result, err := mcp.NewToolResultJSON(response)
+			if err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("failed to encode results: %v", err)), nil
+			}
+			return result, nil
+		}
+
+		// 指摘ごとにコメントを作成し、結果を記録
+		type issueResult struct {
+			Index        int     `json:"index"`
+			CommentID    string  `json:"comment_id,omitempty"`
+			Anchor       string  `json:"anchor,omitempty"`
+			MatchedText  string  `json:"matched_text,omitempty"`
+			Confidence   float64 `json:"confidence,omitempty"`
+			AnchorStatus string  `json:"anchor_status,omitempty"`
+			AnchorReason string  `json:"anchor_reason,omitempty"`
+			NamedRange   string  `json:"named_range,omitempty"`
+			Skipped      bool    `json:"skipped,omitempty"`
+			DuplicateOf  string  `json:"duplicate_of,omitempty"`
+			Error        string  `json:"error,omitempty"`
+		}
+
+		// 指摘を並行して投稿する。失敗した指摘があっても他の指摘の結果は返す
+		posted, err := commentMgr.CreateCommentsFromIssues(ctx, docID, args.Issues, nil)
+		if posted == nil {
+			return mcp.NewToolResultError(fmt.Sprintf("failed to post issues: %v", err)), nil
+		}
+
+		results := make([]issueResult, 0, len(posted.Items))
+		failed := 0
+		for _, item := range posted.Items {
+			switch {
+			case item.Err != nil:
+				failed++
+				results = append(results, issueResult{Index: item.Index, Error: item.Err.Error()})
+				continue
+			case item.Skipped != nil:
+				r := issueResult{Index: item.Index, Skipped: true}
+				if item.Skipped.Duplicate != nil {
+					r.DuplicateOf = item.Skipped.Duplicate.ID
+				}
+				results = append(results, r)
+				continue
+			case item.Response == nil:
+				continue
+			}
+			resp := item.Response
+			results = append(results, issueResult{
+				Index:        item.Index,
+				CommentID:    resp.CommentID,
+				Anchor:       resp.Anchor,
+				MatchedText:  resp.MatchedText,
+				Confidence:   resp.Confidence,
+				AnchorStatus: string(resp.AnchorStatus),
+				AnchorReason: resp.AnchorReason,
+				NamedRange:   resp.NamedRange,
+			})
+		}
+
+		// 結果をJSONで返す
+		result, err := mcp.NewToolResultJSON(map[string]any{
+			"posted":  len(posted.Responses),
+			"skipped": len(posted.Skipped),
+			"failed":  failed,
+			"results": results,
+		})
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("failed to encode results: %v", err)), nil
+		}
+		return result, nil
+	})
+
+	// 10. find_named_range - 名前付き範囲の位置を取得
+	findNamedRangeTool := mcp.NewTool("find_named_range",
+		mcp.WithDescription("Find the current location of a finding by the named range recorded in its comment"),
+		mcp.WithString("url",
+			mcp.Required(),
+			mcp.Description("The Google Docs URL"),
+		),
+		mcp.WithString("name",
+			mcp.Required(),
+			mcp.Description("The named range name, as recorded in the comment body"),
+		),
+	)
+
+	s.AddTool(findNamedRangeTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		// パラメータを取得
+		url, err := request.RequireString("url")
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		name, err := request.RequireString("name")
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		// URLからドキュメントIDを抽出
+		docID, err := review.ExtractDocumentID(url)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("invalid URL: %v", err)), nil
+		}
+
+		// 名前付き範囲を検索
+		positions, err := commentMgr.FindNamedRange(ctx, docID, name)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("failed to find named range: %v", err)), nil
+		}
+
+		// 範囲ごとの位置とテキストを記録
+		type rangeResult struct {
+			SegmentID  string `json:"segment_id,omitempty"`
+			StartIndex int64  `json:"start_index"`
+			EndIndex   int64  `json:"end_index"`
+			Text       string `json:"text"`
+		}
+
+		ranges := make([]rangeResult, 0, len(positions))
+		for _, pos := range positions {
+			ranges = append(ranges, rangeResult{
+				SegmentID:  pos.SegmentID,
+				StartIndex: pos.StartIndex,
+				EndIndex:   pos.EndIndex,
+				Text:       pos.MatchedText,
+			})
+		}
+
+		// 結果をJSONで返す
+		result, err := mcp.NewToolResultJSON(map[string]any{"name": name, "ranges": ranges})
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("failed to encode result: %v", err)), nil
+		}
+		return result, nil
+	})
+
+	// 11. apply_suggestions - 修正案の適用（プレビューと確認付き）
+	applySuggestionsTool := mcp.NewTool("apply_suggestions",
+		mcp.WithDescription("Apply concrete text replacements to a Google Doc. Without confirm=true only a preview diff is returned. Edits are applied to a copy of the document unless in_place is set, because the Docs API cannot create tracked suggestions."),
+		mcp.WithString("url",
+			mcp.Required(),
+			mcp.Description("The Google Docs URL"),
+		),
+		mcp.WithArray("edits",
+			mcp.Required(),
+			mcp.Description("The replacements to apply"),
+			mcp.Items(map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"quoted_text":    map[string]any{"type": "string", "description": "Text in the document to replace"},
+					"replacement":    map[string]any{"type": "string", "description": "New text, empty to delete quoted_text"},
+					"occurrence":     map[string]any{"type": "number", "description": "Which occurrence of quoted_text is meant (1-based)"},
+					"context_before": map[string]any{"type": "string", "description": "Text immediately before quoted_text"},
+					"context_after":  map[string]any{"type": "string", "description": "Text immediately after quoted_text"},
+					"replace_all":    map[string]any{"type": "boolean", "description": "Replace every exact occurrence of quoted_text"},
+				},
+				"required": []string{"quoted_text", "replacement"},
+			}),
+		),
+		mcp.WithBoolean("confirm",
+			mcp.Description("Apply the edits. Requires revision_id from a preview (default: false, preview only)"),
+		),
+		mcp.WithString("revision_id",
+			mcp.Description("Revision returned by the preview, required with confirm=true; the edits are refused if the document changed since"),
+		),
+		mcp.WithBoolean("in_place",
+			mcp.Description("Optional: Edit the document itself instead of a copy (default: false)"),
+		),
+	)
+
+	s.AddTool(applySuggestionsTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		// パラメータを取得
+		url, err := request.RequireString("url")
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		var args struct {
+			Edits []comment.Edit `json:"edits"`
+		}
+		if err := request.BindArguments(&args); err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("invalid edits: %v", err)), nil
+		}
+		if len(args.Edits) == 0 {
+			return mcp.NewToolResultError("edits must contain at least one edit"), nil
+		}
+
+		// URLからドキュメントIDを抽出
+		docID, err := review.ExtractDocumentID(url)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("invalid URL: %v", err)), nil
+		}
+
+		// 修正ごとの位置とエラーを記録
+		type editResult struct {
+			Index       int    `json:"index"`
+			Occurrences int    `json:"occurrences,omitempty"`
+			MatchedText string `json:"matched_text,omitempty"`
+			Error       string `json:"error,omitempty"`
+		}
+		editResults := func(preview *comment.SuggestionPreview) []editResult {
+			results := make([]editResult, 0, len(preview.Edits))
+			for _, edit := range preview.Edits {
+				r := editResult{Index: edit.Index, Occurrences: len(edit.Positions)}
+				if len(edit.Positions) > 0 {
+					r.MatchedText = edit.Positions[0].MatchedText
+				}
+				if edit.Err != nil {
+					r.Error = edit.Err.Error()
+				}
+				results = append(results, r)
+			}
+			return results
+		}
+
+		// 確認がなければプレビューのみ返す
+		if !request.GetBool("confirm", false) {
+			preview, err := commentMgr.PreviewSuggestions(ctx, docID, args.Edits)
+			if err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("failed to preview edits: %v", err)), nil
+			}
+
+			result, err := mcp.NewToolResultJSON(map[string]any{
+				"confirmed":   false,
+				"revision_id": preview.RevisionID,
+				"diff":        preview.Diff(),
+				"failed":      preview.Failed(),
+				"results":     editResults(preview),
+				"message":     "Review the diff, then call apply_suggestions again with confirm=true and this revision_id to apply the edits",
+			})
+			if err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("failed to encode result: %v", err)), nil
+			}
+			return result, nil
+		}
+
+		// プレビューを経ずに適用しないよう、プレビューのリビジョンを必須とする
+		revisionID := request.GetString("revision_id", "")
+		if revisionID == "" {
+			return mcp.NewToolResultError("revision_id is required with confirm=true; call apply_suggestions without confirm first to preview the edits"), nil
+		}
+
+		// 修正を適用
+		applied, err := commentMgr.ApplySuggestions(ctx, docID, args.Edits, &comment.ApplyOptions{
+			InPlace:    request.GetBool("in_place", false),
+			RevisionID: revisionID,
+		})
+		if err != nil {
+			// コピーが作成済みなら、未編集のコピーが残っていることを伝える
+			if applied != nil && applied.Copied {
+				return mcp.NewToolResultError(fmt.Sprintf("failed to apply edits: %v\nThe unedited copy remains at https://docs.google.com/document/d/%s/edit", err, applied.DocumentID)), nil
+			}
+			return mcp.NewToolResultError(fmt.Sprintf("failed to apply edits: %v", err)), nil
+		}
+
+		// 結果をJSONで返す
+		result, err := mcp.NewToolResultJSON(map[string]any{
+			"confirmed":   true,
+			"document_id": applied.DocumentID,
+			"url":         fmt.Sprintf("https://docs.google.com/document/d/%s/edit", applied.DocumentID),
+			"copied":      applied.Copied,
+			"applied":     applied.Applied,
+			"failed":      applied.Preview.Failed(),
+			"diff":        applied.Preview.Diff(),
+			"results":     editResults(applied.Preview),
+		})
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("failed to encode result: %v", err)), nil
+		}
+		return result, nil
+	})
+
+	// 12. diff_google_doc - リビジョン間の差分
+	diffGoogleDocTool := mcp.NewTool("diff_google_doc",
+		mcp.WithDescription("Show the paragraphs of a Google Doc that changed since a past revision, grouped by section, to focus a re-review on what changed"),
+		mcp.WithString("url",
+			mcp.Required(),
+			mcp.Description("The Google Docs URL"),
+		),
+		mcp.WithString("revision_id",
+			mcp.Description("Optional: Revision to compare the current document with (default: the revision before the current one)"),
+		),
+	)
+
+	s.AddTool(diffGoogleDocTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		// パラメータを取得
+		url, err := request.RequireString("url")
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		revisionID := request.GetString("revision_id", "")
+
+		// URLからドキュメントIDを抽出
+		docID, err := review.ExtractDocumentID(url)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("invalid URL: %v", err)), nil
+		}
+
+		// 差分を取得
+		diff, err := fetcher.DiffRevision(ctx, docID, revisionID)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("failed to diff document: %v", err)), nil
+		}
+
+		// 結果をJSONで返す
+		result, err := mcp.NewToolResultJSON(diff)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("failed to encode result: %v", err)), nil
+		}
+		return result, nil
+	})
+
+	// 13. purge_bot_comments - このツールが投稿したコメントの一括削除
+	purgeBotCommentsTool := mcp.NewTool("purge_bot_comments",
+		mcp.WithDescription("Delete the comments this tool posted on a Google Doc. Only comments authored by the authenticated user that carry the tool signature are deleted; other reviewers' comments are never touched. Without confirm=true only the comments that would be deleted are listed."),
+		mcp.WithString("url",
+			mcp.Required(),
+			mcp.Description("The Google Docs URL"),
+		),
+		mcp.WithBoolean("confirm",
+			mcp.Description("Delete the listed comments. Run without it first to review them (default: false, dry run)"),
+		),
+	)
+
+	s.AddTool(purgeBotCommentsTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		// パラメータを取得
+		url, err := request.RequireString("url")
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		confirm := request.GetBool("confirm", false)
+
+		// URLからドキュメントIDを抽出
+		docID, err := review.ExtractDocumentID(url)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("invalid URL: %v", err)), nil
+		}
+
+		// confirmが指定されていなければ削除対象の一覧だけを返す
+		purged, err := commentMgr.PurgeBotComments(ctx, docID, &comment.PurgeOptions{Confirm: confirm})
+		if purged == nil {
+			return mcp.NewToolResultError(fmt.Sprintf("failed to purge comments: %v", err)), nil
+		}
+
+		errs := make([]string, 0, len(purged.Errors))
+		for _, e := range purged.Errors {
+			errs = append(errs, e.Error())
+		}
+
+		// 結果をJSONで返す
+		result, err := mcp.NewToolResultJSON(map[string]any{
+			"dry_run": !confirm,
+			"matched": purged.Matched,
+			"deleted": purged.Deleted,
+			"errors":  errs,
+		})
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("failed to encode result: %v", err)), nil
+		}
+		return result, nil
+	})
+
 	// Start the stdio server
 	if err := server.ServeStdio(s); err != nil {
 		return fmt.Errorf("server error: %w", err)
@@ -0,0 +1,281 @@
+// Package retry retries Google API requests that failed because of quota limits or server errors.
+package retry
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"strconv"
+	"syscall"
+	"time"
+
+	"google.golang.org/api/googleapi"
+)
+
+const (
+	// DefaultMaxAttempts is the number of times a request is sent before its failure is returned
+	DefaultMaxAttempts = 5
+	// DefaultBaseDelay is the backoff before the first retry
+	DefaultBaseDelay = 500 * time.Millisecond
+	// DefaultMaxDelay caps the backoff and the Retry-After delay between two attempts
+	DefaultMaxDelay = 30 * time.Second
+)
+
+// Config controls how requests are retried. Zero values mean the defaults.
+type Config struct {
+	MaxAttempts int           // Number of attempts including the first one, 1 disables retries
+	BaseDelay   time.Duration // Backoff before the first retry, doubled on each further retry
+	MaxDelay    time.Duration // Upper bound of the delay between two attempts
+}
+
+// withDefaults returns the config with zero values replaced by the defaults
+func (c Config) withDefaults() Config {
+	if c.MaxAttempts <= 0 {
+		c.MaxAttempts = DefaultMaxAttempts
+	}
+	if c.BaseDelay <= 0 {
+		c.BaseDelay = DefaultBaseDelay
+	}
+	if c.MaxDelay <= 0 {
+		c.MaxDelay = DefaultMaxDelay
+	}
+	return c
+}
+
+// Backoff returns the jittered delay before the given retry (1 for the first retry).
+// The delay is drawn uniformly from [0, min(MaxDelay, BaseDelay*2^(retry-1))].
+func (c Config) Backoff(retry int) time.Duration {
+	c = c.withDefaults()
+
+	ceiling := c.MaxDelay
+	if shift := retry - 1; shift < 32 {
+		if d := c.BaseDelay << shift; d > 0 && d < ceiling {
+			ceiling = d
+		}
+	}
+	return rand.N(ceiling + 1)
+}
+
+// Transport is an http.RoundTripper that retries requests answered with a quota error (429, or 403
+// with a rate limit reason) or a server error (500, 502, 503, 504). Only methods that are safe to
+// repeat (GET, HEAD, OPTIONS, PUT, DELETE) are retried, so a comment is never posted twice.
+type Transport struct {
+	Base   http.RoundTripper // Transport sending the requests (nil means http.DefaultTransport)
+	Config Config
+
+	// wait sleeps between two attempts, replaced in tests
+	wait func(ctx context.Context, d time.Duration) error
+}
+
+// NewClient returns a copy of client whose requests are retried according to cfg
+func NewClient(client *http.Client, cfg Config) *http.Client {
+	retrying := *client
+	retrying.Transport = &Transport{Base: client.Transport, Config: cfg}
+	return &retrying
+}
+
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	cfg := t.Config.withDefaults()
+	if !idempotent(req.Method) || (req.Body != nil && req.Body != http.NoBody && req.GetBody == nil) {
+		return t.base().RoundTrip(req)
+	}
+
+	for attempt := 1; ; attempt++ {
+		if attempt > 1 && req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			req = req.Clone(req.Context())
+			req.Body = body
+		}
+
+		resp, err := t.base().RoundTrip(req)
+		if err != nil || attempt >= cfg.MaxAttempts || !Retryable(resp) {
+			return resp, err
+		}
+
+		delay := cfg.Backoff(attempt)
+		if retryAfter, ok := parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()); ok {
+			delay = min(retryAfter, cfg.MaxDelay)
+		}
+
+		// Drain the failed response so its connection can be reused
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+
+		if err := t.sleep(req.Context(), delay); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// Do calls fn until it succeeds, fails with an error RetryableError rejects, or MaxAttempts is
+// reached, waiting with jittered backoff or the Retry-After of the error between attempts.
+// Use it for calls the Transport does not retry because the caller knows how to make them safe.
+func Do(ctx context.Context, cfg Config, fn func(attempt int) error) error {
+	cfg = cfg.withDefaults()
+
+	for attempt := 1; ; attempt++ {
+		err := fn(attempt)
+		if err == nil || attempt >= cfg.MaxAttempts || !RetryableError(err) || ctx.Err() != nil {
+			return err
+		}
+
+		delay := cfg.Backoff(attempt)
+		var apiErr *googleapi.Error
+		if errors.As(err, &apiErr) {
+			if retryAfter, ok := parseRetryAfter(apiErr.Header.Get("Retry-After"), time.Now()); ok {
+				delay = min(retryAfter, cfg.MaxDelay)
+			}
+		}
+
+		if err := Sleep(ctx, delay); err != nil {
+			return err
+		}
+	}
+}
+
+// RetryableError reports whether an error returned by a Google API call is transient: a quota or
+// server error, or a network failure after which the request may or may not have been processed.
+func RetryableError(err error) bool {
+	var apiErr *googleapi.Error
+	if errors.As(err, &apiErr) {
+		switch apiErr.Code {
+		case http.StatusTooManyRequests,
+			http.StatusInternalServerError,
+			http.StatusBadGateway,
+			http.StatusServiceUnavailable,
+			http.StatusGatewayTimeout:
+			return true
+		case http.StatusForbidden:
+			for _, e := range apiErr.Errors {
+				if rateLimitReasons[e.Reason] {
+					return true
+				}
+			}
+		}
+		return false
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+	return errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, syscall.ECONNRESET)
+}
+
+// Sleep waits for d or until ctx is done
+func Sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// base returns the transport sending the requests
+func (t *Transport) base() http.RoundTripper {
+	if t.Base != nil {
+		return t.Base
+	}
+	return http.DefaultTransport
+}
+
+// sleep waits for d or until ctx is done
+func (t *Transport) sleep(ctx context.Context, d time.Duration) error {
+	if t.wait != nil {
+		return t.wait(ctx, d)
+	}
+	return Sleep(ctx, d)
+}
+
+// idempotent reports whether a request with the given method can be sent again safely
+func idempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	default:
+		return false
+	}
+}
+
+// Retryable reports whether a response is a transient quota or server error.
+// For 403 the body is inspected for a rate limit reason and left readable.
+func Retryable(resp *http.Response) bool {
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests,
+		http.StatusInternalServerError,
+		http.StatusBadGateway,
+		http.StatusServiceUnavailable,
+		http.StatusGatewayTimeout:
+		return true
+	case http.StatusForbidden:
+		return rateLimited(resp)
+	default:
+		return false
+	}
+}
+
+// rateLimitReasons are the 403 error reasons Google APIs use for exceeded quotas
+var rateLimitReasons = map[string]bool{
+	"rateLimitExceeded":     true,
+	"userRateLimitExceeded": true,
+}
+
+// rateLimited reports whether a 403 response reports an exceeded quota rather than a permission error
+func rateLimited(resp *http.Response) bool {
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	if err != nil {
+		return false
+	}
+
+	var payload struct {
+		Error struct {
+			Errors []struct {
+				Reason string `json:"reason"`
+			} `json:"errors"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return false
+	}
+
+	for _, e := range payload.Error.Errors {
+		if rateLimitReasons[e.Reason] {
+			return true
+		}
+	}
+	return false
+}
+
+// parseRetryAfter parses a Retry-After header given in seconds or as an HTTP date
+func parseRetryAfter(value string, now time.Time) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+
+	if date, err := http.ParseTime(value); err == nil {
+		return max(date.Sub(now), 0), true
+	}
+
+	return 0, false
+}
@@ -0,0 +1,353 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/api/googleapi"
+)
+
+// response is a canned response of the test server
+type response struct {
+	status     int
+	retryAfter string
+	body       string
+}
+
+func TestTransport(t *testing.T) {
+	rateLimitBody := `{"error":{"code":403,"errors":[{"reason":"userRateLimitExceeded"}]}}`
+	forbiddenBody := `{"error":{"code":403,"errors":[{"reason":"insufficientFilePermissions"}]}}`
+
+	tests := []struct {
+		name         string
+		method       string
+		body         string
+		config       Config
+		responses    []response
+		wantStatus   int
+		wantBody     string
+		wantAttempts int
+		wantDelays   []time.Duration
+	}{
+		{
+			name:         "server errors are retried",
+			method:       http.MethodGet,
+			responses:    []response{{status: 503}, {status: 500}, {status: 200, body: "ok"}},
+			wantStatus:   200,
+			wantBody:     "ok",
+			wantAttempts: 3,
+		},
+		{
+			name:         "retry after is honored",
+			method:       http.MethodGet,
+			responses:    []response{{status: 429, retryAfter: "7"}, {status: 200, body: "ok"}},
+			wantStatus:   200,
+			wantBody:     "ok",
+			wantAttempts: 2,
+			wantDelays:   []time.Duration{7 * time.Second},
+		},
+		{
+			name:         "retry after is capped by max delay",
+			method:       http.MethodGet,
+			config:       Config{MaxDelay: 2 * time.Second},
+			responses:    []response{{status: 429, retryAfter: "120"}, {status: 200, body: "ok"}},
+			wantStatus:   200,
+			wantBody:     "ok",
+			wantAttempts: 2,
+			wantDelays:   []time.Duration{2 * time.Second},
+		},
+		{
+			name:         "403 rate limit is retried",
+			method:       http.MethodDelete,
+			responses:    []response{{status: 403, body: rateLimitBody}, {status: 204}},
+			wantStatus:   204,
+			wantAttempts: 2,
+		},
+		{
+			name:         "403 permission error is not retried",
+			method:       http.MethodGet,
+			responses:    []response{{status: 403, body: forbiddenBody}, {status: 200}},
+			wantStatus:   403,
+			wantBody:     forbiddenBody,
+			wantAttempts: 1,
+		},
+		{
+			name:         "client errors are not retried",
+			method:       http.MethodGet,
+			responses:    []response{{status: 404}, {status: 200}},
+			wantStatus:   404,
+			wantAttempts: 1,
+		},
+		{
+			name:         "POST is not retried",
+			method:       http.MethodPost,
+			body:         `{"content":"comment"}`,
+			responses:    []response{{status: 503}, {status: 200}},
+			wantStatus:   503,
+			wantAttempts: 1,
+		},
+		{
+			name:         "PUT body is sent again",
+			method:       http.MethodPut,
+			body:         `{"content":"comment"}`,
+			responses:    []response{{status: 502}, {status: 200, body: "ok"}},
+			wantStatus:   200,
+			wantBody:     "ok",
+			wantAttempts: 2,
+		},
+		{
+			name:         "last failure is returned after max attempts",
+			method:       http.MethodGet,
+			config:       Config{MaxAttempts: 3},
+			responses:    []response{{status: 503}, {status: 503}, {status: 503, body: "unavailable"}, {status: 200}},
+			wantStatus:   503,
+			wantBody:     "unavailable",
+			wantAttempts: 3,
+		},
+		{
+			name:         "max attempts 1 disables retries",
+			method:       http.MethodGet,
+			config:       Config{MaxAttempts: 1},
+			responses:    []response{{status: 503}, {status: 200}},
+			wantStatus:   503,
+			wantAttempts: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				mu       sync.Mutex
+				attempts int
+			)
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				mu.Lock()
+				resp := tt.responses[attempts]
+				attempts++
+				mu.Unlock()
+
+				body, _ := io.ReadAll(r.Body)
+				if string(body) != tt.body {
+					t.Errorf("attempt %d body = %q, want %q", attempts, body, tt.body)
+				}
+
+				if resp.retryAfter != "" {
+					w.Header().Set("Retry-After", resp.retryAfter)
+				}
+				w.WriteHeader(resp.status)
+				io.WriteString(w, resp.body)
+			}))
+			defer server.Close()
+
+			var delays []time.Duration
+			transport := &Transport{
+				Config: tt.config,
+				wait: func(ctx context.Context, d time.Duration) error {
+					delays = append(delays, d)
+					return nil
+				},
+			}
+			client := &http.Client{Transport: transport}
+
+			var body io.Reader
+			if tt.body != "" {
+				body = strings.NewReader(tt.body)
+			}
+			req, err := http.NewRequest(tt.method, server.URL, body)
+			if err != nil {
+				t.Fatalf("failed to create request: %v", err)
+			}
+
+			resp, err := client.Do(req)
+			if err != nil {
+				t.Fatalf("Do() error = %v", err)
+			}
+			defer resp.Body.Close()
+
+			if resp.StatusCode != tt.wantStatus {
+				t.Errorf("status = %d, want %d", resp.StatusCode, tt.wantStatus)
+			}
+			got, _ := io.ReadAll(resp.Body)
+			if string(got) != tt.wantBody {
+				t.Errorf("body = %q, want %q", got, tt.wantBody)
+			}
+			if attempts != tt.wantAttempts {
+				t.Errorf("attempts = %d, want %d", attempts, tt.wantAttempts)
+			}
+			if tt.wantDelays != nil {
+				if diff := cmp.Diff(tt.wantDelays, delays); diff != "" {
+					t.Errorf("delays mismatch (-want +got):\n%s", diff)
+				}
+			}
+			if len(delays) != attempts-1 {
+				t.Errorf("waited %d times, want %d", len(delays), attempts-1)
+			}
+		})
+	}
+}
+
+func TestTransport_ContextCanceled(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "10")
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	client := NewClient(&http.Client{}, Config{})
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+	if err != nil {
+		t.Fatalf("failed to create request: %v", err)
+	}
+
+	start := time.Now()
+	if _, err := client.Do(req); err == nil {
+		t.Fatal("Do() should return an error when the context is done")
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Do() returned after %v, want it to stop waiting when the context is done", elapsed)
+	}
+}
+
+func TestConfig_Backoff(t *testing.T) {
+	cfg := Config{BaseDelay: 100 * time.Millisecond, MaxDelay: time.Second}
+
+	tests := []struct {
+		retry   int
+		ceiling time.Duration
+	}{
+		{retry: 1, ceiling: 100 * time.Millisecond},
+		{retry: 2, ceiling: 200 * time.Millisecond},
+		{retry: 4, ceiling: 800 * time.Millisecond},
+		{retry: 5, ceiling: time.Second},
+		{retry: 100, ceiling: time.Second},
+	}
+
+	for _, tt := range tests {
+		for range 50 {
+			if d := cfg.Backoff(tt.retry); d < 0 || d > tt.ceiling {
+				t.Errorf("Backoff(%d) = %v, want within [0, %v]", tt.retry, d, tt.ceiling)
+			}
+		}
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name   string
+		value  string
+		want   time.Duration
+		wantOK bool
+	}{
+		{name: "empty", value: ""},
+		{name: "seconds", value: "30", want: 30 * time.Second, wantOK: true},
+		{name: "negative seconds", value: "-1"},
+		{name: "http date", value: "Wed, 01 Jan 2025 00:00:10 GMT", want: 10 * time.Second, wantOK: true},
+		{name: "past http date", value: "Tue, 31 Dec 2024 23:59:00 GMT", want: 0, wantOK: true},
+		{name: "invalid", value: "soon"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := parseRetryAfter(tt.value, now)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("parseRetryAfter(%q) = %v, %v, want %v, %v", tt.value, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestRetryableError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "service unavailable", err: &googleapi.Error{Code: 503}, want: true},
+		{name: "too many requests", err: &googleapi.Error{Code: 429}, want: true},
+		{name: "wrapped server error", err: fmt.Errorf("failed to create comment: %w", &googleapi.Error{Code: 500}), want: true},
+		{name: "403 rate limit", err: &googleapi.Error{Code: 403, Errors: []googleapi.ErrorItem{{Reason: "rateLimitExceeded"}}}, want: true},
+		{name: "403 permission", err: &googleapi.Error{Code: 403, Errors: []googleapi.ErrorItem{{Reason: "insufficientFilePermissions"}}}, want: false},
+		{name: "bad request", err: &googleapi.Error{Code: 400}, want: false},
+		{name: "unexpected EOF", err: fmt.Errorf("read: %w", io.ErrUnexpectedEOF), want: true},
+		{name: "context canceled", err: context.Canceled, want: false},
+		{name: "other error", err: errors.New("invalid argument"), want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := RetryableError(tt.err); got != tt.want {
+				t.Errorf("RetryableError(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDo(t *testing.T) {
+	unavailable := &googleapi.Error{Code: 503, Header: http.Header{"Retry-After": []string{"120"}}}
+
+	tests := []struct {
+		name         string
+		config       Config
+		errs         []error
+		wantErr      error
+		wantAttempts int
+	}{
+		{
+			name:         "succeeds after transient errors",
+			errs:         []error{unavailable, unavailable, nil},
+			wantAttempts: 3,
+		},
+		{
+			name:         "permanent error is returned at once",
+			errs:         []error{&googleapi.Error{Code: 404}, nil},
+			wantErr:      &googleapi.Error{Code: 404},
+			wantAttempts: 1,
+		},
+		{
+			name:         "gives up after max attempts",
+			config:       Config{MaxAttempts: 2},
+			errs:         []error{unavailable, unavailable, nil},
+			wantErr:      unavailable,
+			wantAttempts: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Retry-After is capped by MaxDelay, so the test does not wait for it
+			cfg := tt.config
+			cfg.BaseDelay = time.Millisecond
+			cfg.MaxDelay = time.Millisecond
+
+			attempts := 0
+			err := Do(context.Background(), cfg, func(attempt int) error {
+				attempts++
+				if attempt != attempts {
+					t.Errorf("attempt = %d, want %d", attempt, attempts)
+				}
+				return tt.errs[attempt-1]
+			})
+
+			if diff := cmp.Diff(tt.wantErr, err, cmp.Comparer(func(a, b error) bool { return a.Error() == b.Error() })); diff != "" {
+				t.Errorf("Do() error mismatch (-want +got):\n%s", diff)
+			}
+			if attempts != tt.wantAttempts {
+				t.Errorf("attempts = %d, want %d", attempts, tt.wantAttempts)
+			}
+		})
+	}
+}
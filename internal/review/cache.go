@@ -0,0 +1,188 @@
+package review
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"google.golang.org/api/docs/v1"
+)
+
+// DefaultDocumentCacheTTL is how long a fetched document is used without asking Docs for its revision
+const DefaultDocumentCacheTTL = 30 * time.Second
+
+// DefaultDocumentCacheSize is the number of documents and views a DocumentCache keeps. When it is
+// full the least recently used entry is evicted.
+const DefaultDocumentCacheSize = 32
+
+// DocumentCache caches fetched documents per document and suggestions view. Within the TTL a
+// cached document is returned without any request; after it, the document's revisionId is fetched
+// and the document is only downloaded again when the revision changed.
+// At most DefaultDocumentCacheSize entries are kept. Cached documents are shared and must not be modified.
+type DocumentCache struct {
+	docsService *docs.Service
+	ttl         time.Duration
+	maxEntries  int
+	now         func() time.Time
+
+	mu      sync.Mutex
+	entries map[documentCacheKey]*documentCacheEntry
+}
+
+// documentCacheKey identifies a cached document
+type documentCacheKey struct {
+	documentID string
+	mode       string
+}
+
+// documentCacheEntry is a cached document. Its mutex is held while it is fetched, so concurrent
+// requests for the same document wait for a single download.
+type documentCacheEntry struct {
+	mu        sync.Mutex
+	doc       *docs.Document
+	checkedAt time.Time
+	usedAt    time.Time // Last Get of the entry, guarded by the cache mutex
+}
+
+// NewDocumentCache creates a DocumentCache fetching documents with docsService.
+// A ttl of 0 means DefaultDocumentCacheTTL; a negative ttl checks the revision on every call.
+func NewDocumentCache(docsService *docs.Service, ttl time.Duration) *DocumentCache {
+	if ttl == 0 {
+		ttl = DefaultDocumentCacheTTL
+	}
+	return &DocumentCache{
+		docsService: docsService,
+		ttl:         ttl,
+		maxEntries:  DefaultDocumentCacheSize,
+		now:         time.Now,
+		entries:     make(map[documentCacheKey]*documentCacheEntry),
+	}
+}
+
+// Get returns a document in the given suggestions view, from the cache when it is still current
+func (c *DocumentCache) Get(ctx context.Context, documentID string, view SuggestionsView) (*docs.Document, error) {
+	return c.get(ctx, documentID, view, c.ttl)
+}
+
+// GetCurrent is like Get but always checks the revision of a cached document, ignoring the TTL.
+// It is meant for computing indexes to write at, which must not come from a stale document.
+func (c *DocumentCache) GetCurrent(ctx context.Context, documentID string, view SuggestionsView) (*docs.Document, error) {
+	return c.get(ctx, documentID, view, 0)
+}
+
+// get returns a document, trusting a cached copy checked less than maxAge ago without a request
+func (c *DocumentCache) get(ctx context.Context, documentID string, view SuggestionsView, maxAge time.Duration) (*docs.Document, error) {
+	mode, err := view.mode()
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	key := documentCacheKey{documentID: documentID, mode: mode}
+	entry, ok := c.entries[key]
+	if !ok {
+		entry = &documentCacheEntry{}
+		c.entries[key] = entry
+		c.evict()
+	}
+	entry.usedAt = c.now()
+	c.mu.Unlock()
+
+	entry.mu.Lock()
+	defer entry.mu.Unlock()
+
+	if entry.doc != nil {
+		if c.now().Sub(entry.checkedAt) < maxAge {
+			return entry.doc, nil
+		}
+
+		// Without edit access Docs reports no revision, so the document cannot be validated
+		if entry.doc.RevisionId != "" {
+			revision, err := c.docsService.Documents.Get(documentID).Fields("revisionId").Context(ctx).Do()
+			if err != nil {
+				return nil, fmt.Errorf("failed to get document revision: %w", err)
+			}
+			if revision.RevisionId == entry.doc.RevisionId {
+				entry.checkedAt = c.now()
+				return entry.doc, nil
+			}
+		}
+	}
+
+	doc, err := c.docsService.Documents.Get(documentID).SuggestionsViewMode(mode).Context(ctx).Do()
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch document: %w", err)
+	}
+
+	entry.doc = doc
+	entry.checkedAt = c.now()
+	return doc, nil
+}
+
+// evict drops the least recently used entries while the cache holds more than maxEntries.
+// An entry being fetched is only dropped from the map, the running Get still returns its document.
+// It must be called with c.mu held.
+func (c *DocumentCache) evict() {
+	for len(c.entries) > c.maxEntries {
+		var (
+			oldest   documentCacheKey
+			oldestAt time.Time
+			found    bool
+		)
+		for key, entry := range c.entries {
+			// A new entry has a zero usedAt and is never the one evicted
+			if entry.usedAt.IsZero() {
+				continue
+			}
+			if !found || entry.usedAt.Before(oldestAt) {
+				oldest, oldestAt, found = key, entry.usedAt, true
+			}
+		}
+		if !found {
+			return
+		}
+		delete(c.entries, oldest)
+	}
+}
+
+// Revise updates the cached views of a document after a change that moves no text, such as
+// creating a named range, so they need not be downloaded again. revise is called with a shallow
+// copy of each cached document and must replace fields rather than modify them in place, as the
+// previous copy may still be in use.
+//
+// revise should keep the revision ID of the copy, so the next revision check still fetches the
+// document and picks up any edits made by others in the meantime. It may only set the revision
+// after the change when the change is known to be the only one since the cached revision.
+func (c *DocumentCache) Revise(documentID string, revise func(doc *docs.Document)) {
+	c.mu.Lock()
+	entries := make([]*documentCacheEntry, 0)
+	for key, entry := range c.entries {
+		if key.documentID == documentID {
+			entries = append(entries, entry)
+		}
+	}
+	c.mu.Unlock()
+
+	for _, entry := range entries {
+		entry.mu.Lock()
+		if entry.doc != nil {
+			doc := *entry.doc
+			revise(&doc)
+			entry.doc = &doc
+		}
+		entry.mu.Unlock()
+	}
+}
+
+// Invalidate drops the cached views of a document, to be called after modifying it
+func (c *DocumentCache) Invalidate(documentID string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for key := range c.entries {
+		if key.documentID == documentID {
+			delete(c.entries, key)
+		}
+	}
+}
@@ -0,0 +1,261 @@
+package review
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"google.golang.org/api/docs/v1"
+	"google.golang.org/api/option"
+)
+
+// docsServer serves a document whose revision can be changed, counting the requests
+type docsServer struct {
+	mu        sync.Mutex
+	revision  string
+	fetches   map[string]int // Full fetches per suggestions view mode
+	revisions int            // Fetches of the revision only
+}
+
+func (s *docsServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if r.Method != http.MethodGet || r.URL.Path != "/v1/documents/doc-1" {
+		http.NotFound(w, r)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if r.URL.Query().Get("fields") == "revisionId" {
+		s.revisions++
+		json.NewEncoder(w).Encode(&docs.Document{RevisionId: s.revision})
+		return
+	}
+
+	mode := r.URL.Query().Get("suggestionsViewMode")
+	s.fetches[mode]++
+	json.NewEncoder(w).Encode(&docs.Document{DocumentId: "doc-1", Title: mode, RevisionId: s.revision})
+}
+
+func (s *docsServer) setRevision(revision string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.revision = revision
+}
+
+// newTestDocumentCache creates a DocumentCache backed by a docsServer and a controllable clock
+func newTestDocumentCache(t *testing.T, ttl time.Duration) (*DocumentCache, *docsServer, *time.Time) {
+	t.Helper()
+
+	backend := &docsServer{revision: "rev-1", fetches: make(map[string]int)}
+	server := httptest.NewServer(backend)
+	t.Cleanup(server.Close)
+
+	docsService, err := docs.NewService(context.Background(),
+		option.WithHTTPClient(server.Client()),
+		option.WithEndpoint(server.URL+"/"),
+	)
+	if err != nil {
+		t.Fatalf("failed to create Docs service: %v", err)
+	}
+
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	cache := NewDocumentCache(docsService, ttl)
+	cache.now = func() time.Time { return now }
+	return cache, backend, &now
+}
+
+func TestDocumentCache_Get(t *testing.T) {
+	tests := []struct {
+		name          string
+		advance       time.Duration
+		newRevision   string
+		invalidate    bool
+		current       bool
+		wantFetches   int
+		wantRevisions int
+	}{
+		{
+			name:        "within TTL no request is made",
+			advance:     10 * time.Second,
+			wantFetches: 1,
+		},
+		{
+			name:          "after TTL an unchanged revision is reused",
+			advance:       time.Minute,
+			wantFetches:   1,
+			wantRevisions: 1,
+		},
+		{
+			name:          "after TTL a new revision is fetched",
+			advance:       time.Minute,
+			newRevision:   "rev-2",
+			wantFetches:   2,
+			wantRevisions: 1,
+		},
+		{
+			name:        "within TTL a new revision is not noticed",
+			advance:     10 * time.Second,
+			newRevision: "rev-2",
+			wantFetches: 1,
+		},
+		{
+			name:          "current within TTL checks the revision",
+			advance:       10 * time.Second,
+			current:       true,
+			wantFetches:   1,
+			wantRevisions: 1,
+		},
+		{
+			name:          "current within TTL fetches a new revision",
+			advance:       10 * time.Second,
+			newRevision:   "rev-2",
+			current:       true,
+			wantFetches:   2,
+			wantRevisions: 1,
+		},
+		{
+			name:        "invalidate fetches again",
+			invalidate:  true,
+			wantFetches: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cache, backend, now := newTestDocumentCache(t, 30*time.Second)
+			ctx := context.Background()
+
+			if _, err := cache.Get(ctx, "doc-1", SuggestionsViewDefault); err != nil {
+				t.Fatalf("Get() error = %v", err)
+			}
+
+			*now = now.Add(tt.advance)
+			if tt.newRevision != "" {
+				backend.setRevision(tt.newRevision)
+			}
+			if tt.invalidate {
+				cache.Invalidate("doc-1")
+			}
+
+			get := cache.Get
+			if tt.current {
+				get = cache.GetCurrent
+			}
+			doc, err := get(ctx, "doc-1", SuggestionsViewDefault)
+			if err != nil {
+				t.Fatalf("Get() error = %v", err)
+			}
+
+			if got := backend.fetches["DEFAULT_FOR_CURRENT_ACCESS"]; got != tt.wantFetches {
+				t.Errorf("fetches = %d, want %d", got, tt.wantFetches)
+			}
+			if backend.revisions != tt.wantRevisions {
+				t.Errorf("revision checks = %d, want %d", backend.revisions, tt.wantRevisions)
+			}
+			if tt.wantFetches > 1 && doc.RevisionId != backend.revision {
+				t.Errorf("RevisionId = %q, want %q", doc.RevisionId, backend.revision)
+			}
+		})
+	}
+}
+
+func TestDocumentCache_Views(t *testing.T) {
+	cache, backend, _ := newTestDocumentCache(t, 0)
+	ctx := context.Background()
+
+	for _, view := range []SuggestionsView{SuggestionsViewDefault, SuggestionsViewInline, SuggestionsViewDefault, SuggestionsViewInline} {
+		doc, err := cache.Get(ctx, "doc-1", view)
+		if err != nil {
+			t.Fatalf("Get(%q) error = %v", view, err)
+		}
+		if mode, _ := view.mode(); doc.Title != mode {
+			t.Errorf("Get(%q) returned the %q view", view, doc.Title)
+		}
+	}
+
+	if backend.fetches["DEFAULT_FOR_CURRENT_ACCESS"] != 1 || backend.fetches["SUGGESTIONS_INLINE"] != 1 {
+		t.Errorf("fetches = %v, want one per view", backend.fetches)
+	}
+
+	if _, err := cache.Get(ctx, "doc-1", "unknown"); err == nil {
+		t.Error("Get() with an unknown view should return error")
+	}
+}
+
+func TestDocumentCache_Concurrent(t *testing.T) {
+	cache, backend, _ := newTestDocumentCache(t, 0)
+
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Go(func() {
+			if _, err := cache.Get(context.Background(), "doc-1", SuggestionsViewDefault); err != nil {
+				t.Errorf("Get() error = %v", err)
+			}
+		})
+	}
+	wg.Wait()
+
+	if got := backend.fetches["DEFAULT_FOR_CURRENT_ACCESS"]; got != 1 {
+		t.Errorf("fetches = %d, want 1", got)
+	}
+}
+
+func TestDocumentCache_Evict(t *testing.T) {
+	cache, backend, now := newTestDocumentCache(t, 0)
+	cache.maxEntries = 1
+	ctx := context.Background()
+
+	for _, view := range []SuggestionsView{SuggestionsViewDefault, SuggestionsViewInline, SuggestionsViewInline, SuggestionsViewDefault} {
+		*now = now.Add(time.Second)
+		if _, err := cache.Get(ctx, "doc-1", view); err != nil {
+			t.Fatalf("Get(%q) error = %v", view, err)
+		}
+		if len(cache.entries) > 1 {
+			t.Fatalf("cache holds %d entries, want at most 1", len(cache.entries))
+		}
+	}
+
+	// The default view was evicted by the inline view and fetched again
+	if backend.fetches["DEFAULT_FOR_CURRENT_ACCESS"] != 2 || backend.fetches["SUGGESTIONS_INLINE"] != 1 {
+		t.Errorf("fetches = %v, want the evicted default view fetched twice", backend.fetches)
+	}
+}
+
+func TestDocumentCache_Revise(t *testing.T) {
+	cache, backend, now := newTestDocumentCache(t, 30*time.Second)
+	ctx := context.Background()
+
+	original, err := cache.Get(ctx, "doc-1", SuggestionsViewDefault)
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+
+	cache.Revise("doc-1", func(doc *docs.Document) { doc.Title = "revised" })
+	backend.setRevision("rev-2")
+
+	doc, err := cache.Get(ctx, "doc-1", SuggestionsViewDefault)
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if doc.Title != "revised" || original.Title == "revised" {
+		t.Errorf("Title = %q (original %q), want a revised copy", doc.Title, original.Title)
+	}
+	if got := backend.fetches["DEFAULT_FOR_CURRENT_ACCESS"]; got != 1 {
+		t.Errorf("fetches = %d, want 1 within the TTL", got)
+	}
+
+	// After the TTL the changed revision is downloaded
+	*now = now.Add(time.Minute)
+	if _, err := cache.Get(ctx, "doc-1", SuggestionsViewDefault); err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if got := backend.fetches["DEFAULT_FOR_CURRENT_ACCESS"]; got != 2 {
+		t.Errorf("fetches = %d, want 2 after the TTL", got)
+	}
+}
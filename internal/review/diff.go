@@ -0,0 +1,168 @@
+package review
+
+import (
+	"strings"
+)
+
+// ChangeKind is the kind of a paragraph change between two versions of a document
+type ChangeKind string
+
+const (
+	ChangeAdded    ChangeKind = "added"
+	ChangeRemoved  ChangeKind = "removed"
+	ChangeModified ChangeKind = "modified"
+)
+
+// ParagraphChange is a paragraph that differs between a base and a head version of a document
+type ParagraphChange struct {
+	Kind    ChangeKind `json:"kind"`
+	Section string     `json:"section,omitempty"`  // Heading of the head section the change falls in
+	OldText string     `json:"old_text,omitempty"` // Paragraph in the base version, empty when added
+	NewText string     `json:"new_text,omitempty"` // Paragraph in the head version, empty when removed
+	OldLine int        `json:"old_line,omitempty"` // 1-based paragraph number in the base version
+	NewLine int        `json:"new_line,omitempty"` // 1-based paragraph number in the head version
+}
+
+// splitParagraphs splits exported plain text into paragraphs, dropping blank lines
+func splitParagraphs(text string) []string {
+	text = strings.TrimPrefix(text, "\ufeff")
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+
+	paragraphs := make([]string, 0)
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" {
+			paragraphs = append(paragraphs, line)
+		}
+	}
+	return paragraphs
+}
+
+// diffParagraphs returns the paragraph-level changes from base to head.
+// Removed paragraphs directly followed by added ones are paired up as modifications.
+// headings are the section titles of the head version, used to attribute each change to a section.
+func diffParagraphs(base, head []string, headings []string) []*ParagraphChange {
+	isHeading := make(map[string]bool, len(headings))
+	for _, heading := range headings {
+		isHeading[strings.TrimSpace(heading)] = true
+	}
+
+	changes := make([]*ParagraphChange, 0)
+	var (
+		section string
+		removed []*ParagraphChange
+		added   []*ParagraphChange
+	)
+
+	// flush pairs up the pending removals and additions of one hunk
+	flush := func() {
+		paired := min(len(removed), len(added))
+		for i := 0; i < paired; i++ {
+			changes = append(changes, &ParagraphChange{
+				Kind:    ChangeModified,
+				Section: added[i].Section,
+				OldText: removed[i].OldText,
+				NewText: added[i].NewText,
+				OldLine: removed[i].OldLine,
+				NewLine: added[i].NewLine,
+			})
+		}
+		changes = append(changes, removed[paired:]...)
+		changes = append(changes, added[paired:]...)
+		removed, added = nil, nil
+	}
+
+	for _, op := range lcsDiff(base, head) {
+		if op.newLine > 0 && isHeading[head[op.newLine-1]] {
+			section = head[op.newLine-1]
+		}
+
+		switch {
+		case op.oldLine > 0 && op.newLine > 0:
+			flush()
+		case op.oldLine > 0:
+			removed = append(removed, &ParagraphChange{Kind: ChangeRemoved, Section: section, OldText: base[op.oldLine-1], OldLine: op.oldLine})
+		default:
+			added = append(added, &ParagraphChange{Kind: ChangeAdded, Section: section, NewText: head[op.newLine-1], NewLine: op.newLine})
+		}
+	}
+	flush()
+
+	return changes
+}
+
+// ChangedSections returns the sections with at least one change, in order of first change
+func ChangedSections(changes []*ParagraphChange) []string {
+	seen := make(map[string]bool)
+	sections := make([]string, 0)
+	for _, change := range changes {
+		if !seen[change.Section] {
+			seen[change.Section] = true
+			sections = append(sections, change.Section)
+		}
+	}
+	return sections
+}
+
+// diffOp is one step of an edit script: a kept paragraph has both line numbers,
+// a removed one only oldLine and an added one only newLine. Line numbers are 1-based.
+type diffOp struct {
+	oldLine int
+	newLine int
+}
+
+// lcsDiff returns an edit script from a to b based on their longest common subsequence
+func lcsDiff(a, b []string) []diffOp {
+	// Common prefix and suffix are kept as is
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	ops := make([]diffOp, 0, len(a)+len(b))
+	for i := 0; i < prefix; i++ {
+		ops = append(ops, diffOp{oldLine: i + 1, newLine: i + 1})
+	}
+
+	// lengths[i][j] is the LCS length of midA[i:] and midB[j:]
+	midA, midB := a[prefix:len(a)-suffix], b[prefix:len(b)-suffix]
+	lengths := make([][]int, len(midA)+1)
+	for i := range lengths {
+		lengths[i] = make([]int, len(midB)+1)
+	}
+	for i := len(midA) - 1; i >= 0; i-- {
+		for j := len(midB) - 1; j >= 0; j-- {
+			if midA[i] == midB[j] {
+				lengths[i][j] = lengths[i+1][j+1] + 1
+			} else {
+				lengths[i][j] = max(lengths[i+1][j], lengths[i][j+1])
+			}
+		}
+	}
+
+	i, j := 0, 0
+	for i < len(midA) || j < len(midB) {
+		switch {
+		case i < len(midA) && j < len(midB) && midA[i] == midB[j]:
+			ops = append(ops, diffOp{oldLine: prefix + i + 1, newLine: prefix + j + 1})
+			i++
+			j++
+		case j == len(midB) || (i < len(midA) && lengths[i+1][j] >= lengths[i][j+1]):
+			ops = append(ops, diffOp{oldLine: prefix + i + 1})
+			i++
+		default:
+			ops = append(ops, diffOp{newLine: prefix + j + 1})
+			j++
+		}
+	}
+
+	for k := suffix; k > 0; k-- {
+		ops = append(ops, diffOp{oldLine: len(a) - k + 1, newLine: len(b) - k + 1})
+	}
+
+	return ops
+}
@@ -0,0 +1,112 @@
+package review
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestSplitParagraphs(t *testing.T) {
+	got := splitParagraphs("\ufeff概要\r\n\r\n  本文です。 \r\n詳細\r\n")
+	want := []string{"概要", "本文です。", "詳細"}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("splitParagraphs() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestDiffParagraphs(t *testing.T) {
+	headings := []string{"概要", "設計"}
+
+	tests := []struct {
+		name string
+		base []string
+		head []string
+		want []*ParagraphChange
+	}{
+		{
+			name: "unchanged",
+			base: []string{"概要", "本文"},
+			head: []string{"概要", "本文"},
+			want: []*ParagraphChange{},
+		},
+		{
+			name: "modified paragraph",
+			base: []string{"概要", "テストデザインドッグ", "設計", "テストテスト"},
+			head: []string{"概要", "テストデザインドキュメント", "設計", "テストテスト"},
+			want: []*ParagraphChange{
+				{Kind: ChangeModified, Section: "概要", OldText: "テストデザインドッグ", NewText: "テストデザインドキュメント", OldLine: 2, NewLine: 2},
+			},
+		},
+		{
+			name: "added and removed in different sections",
+			base: []string{"前書き", "概要", "古い説明", "設計", "構成"},
+			head: []string{"前書き", "概要", "設計", "構成", "API仕様"},
+			want: []*ParagraphChange{
+				{Kind: ChangeRemoved, Section: "概要", OldText: "古い説明", OldLine: 3},
+				{Kind: ChangeAdded, Section: "設計", NewText: "API仕様", NewLine: 5},
+			},
+		},
+		{
+			name: "more additions than removals",
+			base: []string{"概要", "a"},
+			head: []string{"概要", "b", "c"},
+			want: []*ParagraphChange{
+				{Kind: ChangeModified, Section: "概要", OldText: "a", NewText: "b", OldLine: 2, NewLine: 2},
+				{Kind: ChangeAdded, Section: "概要", NewText: "c", NewLine: 3},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := diffParagraphs(tt.base, tt.head, headings)
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("diffParagraphs() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestChangedSections(t *testing.T) {
+	changes := []*ParagraphChange{
+		{Section: ""},
+		{Section: "設計"},
+		{Section: ""},
+		{Section: "設計"},
+		{Section: "API"},
+	}
+
+	want := []string{"", "設計", "API"}
+	if diff := cmp.Diff(want, ChangedSections(changes)); diff != "" {
+		t.Errorf("ChangedSections() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestSelectRevision(t *testing.T) {
+	revisions := []*Revision{{ID: "1"}, {ID: "2"}, {ID: "3"}}
+
+	tests := []struct {
+		name       string
+		revisions  []*Revision
+		revisionID string
+		want       string
+		wantErr    bool
+	}{
+		{name: "previous by default", revisions: revisions, want: "2"},
+		{name: "by ID", revisions: revisions, revisionID: "1", want: "1"},
+		{name: "unknown ID", revisions: revisions, revisionID: "9", wantErr: true},
+		{name: "single revision", revisions: revisions[:1], wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := selectRevision(tt.revisions, tt.revisionID)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("selectRevision() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && got.ID != tt.want {
+				t.Errorf("selectRevision() = %s, want %s", got.ID, tt.want)
+			}
+		})
+	}
+}
@@ -2,32 +2,122 @@ package review
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"net/http"
 	"regexp"
 	"strings"
 
 	"google.golang.org/api/docs/v1"
+	"google.golang.org/api/drive/v3"
 	"google.golang.org/api/option"
 )
 
 // GoogleDocFetcher handles fetching content from Google Docs
 type GoogleDocFetcher struct {
-	client *http.Client
+	client       *http.Client
+	docsService  *docs.Service
+	driveService *drive.Service
+	documents    *DocumentCache
+}
+
+// FetcherOption configures a GoogleDocFetcher
+type FetcherOption func(*GoogleDocFetcher)
+
+// WithDocumentCache makes the fetcher share a document cache, e.g. with a CommentManager
+func WithDocumentCache(cache *DocumentCache) FetcherOption {
+	return func(f *GoogleDocFetcher) {
+		f.documents = cache
+	}
 }
 
 // NewGoogleDocFetcher creates a new GoogleDocFetcher
-func NewGoogleDocFetcher(client *http.Client) *GoogleDocFetcher {
-	return &GoogleDocFetcher{
-		client: client,
+func NewGoogleDocFetcher(client *http.Client, opts ...FetcherOption) (*GoogleDocFetcher, error) {
+	ctx := context.Background()
+	docsService, err := docs.NewService(ctx, option.WithHTTPClient(client))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Docs service: %w", err)
+	}
+
+	driveService, err := drive.NewService(ctx, option.WithHTTPClient(client))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Drive service: %w", err)
 	}
+
+	return NewGoogleDocFetcherWithServices(client, docsService, driveService, opts...), nil
+}
+
+// NewGoogleDocFetcherWithServices creates a GoogleDocFetcher using existing Docs and Drive services
+func NewGoogleDocFetcherWithServices(client *http.Client, docsService *docs.Service, driveService *drive.Service, opts ...FetcherOption) *GoogleDocFetcher {
+	f := &GoogleDocFetcher{
+		client:       client,
+		docsService:  docsService,
+		driveService: driveService,
+	}
+
+	for _, opt := range opts {
+		opt(f)
+	}
+	if f.documents == nil {
+		f.documents = NewDocumentCache(docsService, 0)
+	}
+
+	return f
+}
+
+// Documents returns the document cache of the fetcher, to share it with other components
+func (f *GoogleDocFetcher) Documents() *DocumentCache {
+	return f.documents
 }
 
 // Document represents a Google Doc with its content
 type Document struct {
-	ID      string
-	Title   string
-	Content string
+	ID       string     `json:"id"`
+	Title    string     `json:"title"`
+	Content  string     `json:"-"`
+	Sections []*Section `json:"sections"` // Structured body, split by headings
+	Lines    []Line     `json:"lines"`    // Numbered lines, as used for line anchors
+
+	SuggestionsView SuggestionsView `json:"suggestions_view,omitempty"` // How pending suggestions are reflected in the content
+	Suggestions     []*Suggestion   `json:"suggestions,omitempty"`      // Pending suggestions
+}
+
+// FetchOptions controls how a document is fetched
+type FetchOptions struct {
+	// SuggestionsView selects whether the content shows suggestions inline, accepted or rejected.
+	// Line numbers and indexes of the accepted and rejected views do not match the live document,
+	// so they cannot be used for anchors.
+	SuggestionsView SuggestionsView
+}
+
+// Format is an output format for a fetched document
+type Format string
+
+const (
+	FormatText     Format = "text"
+	FormatMarkdown Format = "markdown"
+	FormatJSON     Format = "json"
+	FormatNumbered Format = "numbered" // Text with line numbers and Docs index ranges
+)
+
+// Render renders the document in the given format
+func (d *Document) Render(format Format) (string, error) {
+	switch format {
+	case FormatText, "":
+		return d.Content, nil
+	case FormatMarkdown:
+		return RenderMarkdown(d), nil
+	case FormatNumbered:
+		return RenderNumberedLines(d.Lines), nil
+	case FormatJSON:
+		data, err := json.MarshalIndent(d, "", "  ")
+		if err != nil {
+			return "", fmt.Errorf("failed to marshal document: %w", err)
+		}
+		return string(data), nil
+	default:
+		return "", fmt.Errorf("unsupported format: %s", format)
+	}
 }
 
 // ExtractDocumentID extracts the document ID from a Google Docs URL
@@ -48,37 +138,50 @@ func ExtractDocumentID(url string) (string, error) {
 }
 
 // FetchDocument fetches a Google Doc by URL and returns its content
-func (f *GoogleDocFetcher) FetchDocument(ctx context.Context, url string) (*Document, error) {
+func (f *GoogleDocFetcher) FetchDocument(ctx context.Context, url string, opts *FetchOptions) (*Document, error) {
 	// Extract document ID from URL
 	docID, err := ExtractDocumentID(url)
 	if err != nil {
 		return nil, err
 	}
 
-	return f.FetchDocumentByID(ctx, docID)
+	return f.FetchDocumentByID(ctx, docID, opts)
 }
 
-// FetchDocumentByID fetches a Google Doc by its ID and returns its content
-func (f *GoogleDocFetcher) FetchDocumentByID(ctx context.Context, documentID string) (*Document, error) {
-	// Create Docs service
-	docsService, err := docs.NewService(ctx, option.WithHTTPClient(f.client))
-	if err != nil {
-		return nil, fmt.Errorf("failed to create Docs service: %w", err)
+// FetchDocumentByID fetches a Google Doc by its ID and returns its content.
+// Pending suggestions are listed regardless of the view, which for the accepted and rejected
+// views takes a second, inline fetch.
+func (f *GoogleDocFetcher) FetchDocumentByID(ctx context.Context, documentID string, opts *FetchOptions) (*Document, error) {
+	if opts == nil {
+		opts = &FetchOptions{}
 	}
 
 	// Fetch the document
-	doc, err := docsService.Documents.Get(documentID).Context(ctx).Do()
+	doc, err := f.documents.Get(ctx, documentID, opts.SuggestionsView)
 	if err != nil {
-		return nil, fmt.Errorf("failed to fetch document: %w", err)
+		return nil, err
+	}
+
+	// Suggestions are only marked in the inline view
+	inline := doc
+	if opts.SuggestionsView == SuggestionsViewAccepted || opts.SuggestionsView == SuggestionsViewRejected {
+		inline, err = f.documents.Get(ctx, documentID, SuggestionsViewInline)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch suggestions: %w", err)
+		}
 	}
 
 	// Extract text content from the document
 	content := extractTextFromDocument(doc)
 
 	return &Document{
-		ID:      documentID,
-		Title:   doc.Title,
-		Content: content,
+		ID:              documentID,
+		Title:           doc.Title,
+		Content:         content,
+		Sections:        buildSections(doc),
+		Lines:           BuildLines(doc),
+		SuggestionsView: opts.SuggestionsView,
+		Suggestions:     buildSuggestions(inline),
 	}, nil
 }
 
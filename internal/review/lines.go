@@ -0,0 +1,116 @@
+package review
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf16"
+
+	"google.golang.org/api/docs/v1"
+)
+
+// Line is a single line of a Google Doc as used for line-numbered output and line anchors.
+// Every paragraph in the body (including paragraphs inside table cells) starts a new line,
+// and soft line breaks (Shift+Enter) inside a paragraph split it into further lines.
+type Line struct {
+	Number     int    `json:"number"`
+	Text       string `json:"text"`
+	StartIndex int64  `json:"start_index"`
+	EndIndex   int64  `json:"end_index"` // Exclusive, not including the line break
+}
+
+// BuildLines splits the body of a Google Doc into numbered lines with their Docs index ranges
+func BuildLines(doc *docs.Document) []Line {
+	lines := make([]Line, 0)
+	if doc.Body == nil {
+		return lines
+	}
+
+	for _, element := range doc.Body.Content {
+		appendLines(element, &lines)
+	}
+
+	return lines
+}
+
+// appendLines appends the lines of a structural element, descending into tables
+func appendLines(element *docs.StructuralElement, lines *[]Line) {
+	if element.Paragraph != nil {
+		appendParagraphLines(element, lines)
+	}
+
+	if element.Table != nil {
+		for _, row := range element.Table.TableRows {
+			for _, cell := range row.TableCells {
+				for _, content := range cell.Content {
+					appendLines(content, lines)
+				}
+			}
+		}
+	}
+}
+
+// appendParagraphLines appends the lines of a paragraph, splitting on soft line breaks
+func appendParagraphLines(element *docs.StructuralElement, lines *[]Line) {
+	var text strings.Builder
+	start := element.StartIndex
+	index := element.StartIndex
+	open := false
+
+	flush := func(end int64) {
+		*lines = append(*lines, Line{
+			Number:     len(*lines) + 1,
+			Text:       text.String(),
+			StartIndex: start,
+			EndIndex:   end,
+		})
+		text.Reset()
+		open = false
+	}
+
+	for _, elem := range element.Paragraph.Elements {
+		if elem.TextRun == nil {
+			continue
+		}
+
+		index = elem.StartIndex
+		for _, r := range elem.TextRun.Content {
+			if !open {
+				start = index
+				open = true
+			}
+
+			width := int64(utf16.RuneLen(r))
+			if r == '\n' || r == '\v' {
+				flush(index)
+				index += width
+				continue
+			}
+
+			text.WriteRune(r)
+			index += width
+		}
+	}
+
+	if open {
+		flush(index)
+	}
+}
+
+// LineAt returns the line with the given 1-based number
+func LineAt(lines []Line, number int) (Line, error) {
+	if number <= 0 || number > len(lines) {
+		return Line{}, fmt.Errorf("line %d is out of range (document has %d lines)", number, len(lines))
+	}
+	return lines[number-1], nil
+}
+
+// RenderNumberedLines renders lines as "number<TAB>[start-end)<TAB>text"
+func RenderNumberedLines(lines []Line) string {
+	var builder strings.Builder
+
+	for _, line := range lines {
+		fmt.Fprintf(&builder, "%d\t[%d-%d)\t%s\n", line.Number, line.StartIndex, line.EndIndex, line.Text)
+	}
+
+	return builder.String()
+}
@@ -0,0 +1,84 @@
+package review
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/api/docs/v1"
+)
+
+func TestBuildLines(t *testing.T) {
+	doc := &docs.Document{
+		Body: &docs.Body{
+			Content: []*docs.StructuralElement{
+				{EndIndex: 1, SectionBreak: &docs.SectionBreak{}},
+				{
+					StartIndex: 1,
+					EndIndex:   13,
+					Paragraph: &docs.Paragraph{
+						Elements: []*docs.ParagraphElement{
+							// "テスト" is 3 UTF-16 code units, "😀" is 2
+							{StartIndex: 1, EndIndex: 7, TextRun: &docs.TextRun{Content: "テスト😀\v"}},
+							{StartIndex: 7, EndIndex: 13, TextRun: &docs.TextRun{Content: "second\n"}},
+						},
+					},
+				},
+				paragraphElement(14, "NORMAL_TEXT", "\n"),
+				{
+					StartIndex: 15,
+					EndIndex:   22,
+					Table: &docs.Table{
+						TableRows: []*docs.TableRow{
+							{
+								TableCells: []*docs.TableCell{
+									{Content: []*docs.StructuralElement{paragraphElement(17, "NORMAL_TEXT", "cell\n")}},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	want := []Line{
+		{Number: 1, Text: "テスト😀", StartIndex: 1, EndIndex: 6},
+		{Number: 2, Text: "second", StartIndex: 7, EndIndex: 13},
+		{Number: 3, Text: "", StartIndex: 14, EndIndex: 14},
+		{Number: 4, Text: "cell", StartIndex: 17, EndIndex: 21},
+	}
+
+	if diff := cmp.Diff(want, BuildLines(doc)); diff != "" {
+		t.Errorf("BuildLines() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestLineAt(t *testing.T) {
+	lines := []Line{{Number: 1, Text: "a"}, {Number: 2, Text: "b"}}
+
+	got, err := LineAt(lines, 2)
+	if err != nil {
+		t.Fatalf("LineAt() error = %v", err)
+	}
+	if got.Text != "b" {
+		t.Errorf("LineAt() text = %q, want %q", got.Text, "b")
+	}
+
+	for _, number := range []int{0, 3} {
+		if _, err := LineAt(lines, number); err == nil {
+			t.Errorf("LineAt(%d) should return error", number)
+		}
+	}
+}
+
+func TestRenderNumberedLines(t *testing.T) {
+	lines := []Line{
+		{Number: 1, Text: "Title", StartIndex: 1, EndIndex: 6},
+		{Number: 2, Text: "", StartIndex: 7, EndIndex: 7},
+	}
+
+	want := "1\t[1-6)\tTitle\n2\t[7-7)\t\n"
+	if diff := cmp.Diff(want, RenderNumberedLines(lines)); diff != "" {
+		t.Errorf("RenderNumberedLines() mismatch (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,241 @@
+package review
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// markdownEscaper escapes characters that would otherwise start inline formatting
+var markdownEscaper = strings.NewReplacer(
+	`\`, `\\`,
+	"*", `\*`,
+	"_", `\_`,
+	"`", "\\`",
+)
+
+// blockMarkerPattern matches text at the start of a line that Markdown would read as a heading,
+// block quote or list item
+var blockMarkerPattern = regexp.MustCompile(`^(?:#{1,6}(?:\s|$)|>|[-+](?:\s|$)|(\d{1,9})[.)](?:\s|$))`)
+
+// RenderMarkdown renders the structured document as GitHub Flavored Markdown
+func RenderMarkdown(doc *Document) string {
+	var builder strings.Builder
+
+	for _, section := range doc.Sections {
+		renderSection(section, &builder)
+	}
+
+	return strings.TrimRight(builder.String(), "\n") + "\n"
+}
+
+// renderSection renders a section heading, its blocks and its subsections
+func renderSection(section *Section, builder *strings.Builder) {
+	if section.Heading != nil {
+		text := renderInline(section.Heading.Runs)
+		if text != "" {
+			fmt.Fprintf(builder, "%s %s\n\n", strings.Repeat("#", section.Level), text)
+		}
+	}
+
+	renderBlocks(section.Blocks, builder)
+
+	for _, sub := range section.Sections {
+		renderSection(sub, builder)
+	}
+}
+
+// renderBlocks renders blocks separated by blank lines, keeping consecutive list items together
+func renderBlocks(blocks []Block, builder *strings.Builder) {
+	for i, block := range blocks {
+		switch block.Kind {
+		case BlockTable:
+			renderTable(block.Table, builder)
+			builder.WriteString("\n")
+		case BlockListItem:
+			renderListItem(block.Paragraph, builder)
+			if i+1 >= len(blocks) || blocks[i+1].Kind != BlockListItem {
+				builder.WriteString("\n")
+			}
+		default:
+			text := renderParagraph(block.Paragraph)
+			if text == "" {
+				continue
+			}
+			builder.WriteString(text)
+			builder.WriteString("\n\n")
+		}
+	}
+}
+
+// renderParagraph renders a non-list paragraph, mapping Docs title styles to Markdown
+func renderParagraph(paragraph *Paragraph) string {
+	text := renderInline(paragraph.Runs)
+	if text == "" {
+		return ""
+	}
+
+	switch paragraph.Style {
+	case StyleTitle:
+		return "# " + text
+	case StyleSubtitle:
+		return "*" + text + "*"
+	default:
+		if level := paragraph.Style.HeadingLevel(); level > 0 {
+			return strings.Repeat("#", level) + " " + text
+		}
+		return escapeBlockMarker(text)
+	}
+}
+
+// escapeBlockMarker escapes a leading heading, block quote or list marker so text stays a paragraph
+func escapeBlockMarker(text string) string {
+	match := blockMarkerPattern.FindStringSubmatchIndex(text)
+	if match == nil {
+		return text
+	}
+
+	// Ordered list markers are escaped after the number, e.g. "1\. "
+	if digitsEnd := match[3]; digitsEnd > 0 {
+		return text[:digitsEnd] + `\` + text[digitsEnd:]
+	}
+	return `\` + text
+}
+
+// renderListItem renders a list item with indentation for its nesting level
+func renderListItem(paragraph *Paragraph, builder *strings.Builder) {
+	marker := "-"
+	if paragraph.List.Ordered {
+		marker = "1."
+	}
+
+	indent := strings.Repeat("    ", paragraph.List.NestingLevel)
+	fmt.Fprintf(builder, "%s%s %s\n", indent, marker, escapeBlockMarker(renderInline(paragraph.Runs)))
+}
+
+// renderTable renders a table as a GFM table. The first row is used as the header row.
+func renderTable(table *Table, builder *strings.Builder) {
+	if len(table.Rows) == 0 {
+		return
+	}
+
+	columns := 0
+	for _, row := range table.Rows {
+		if len(row.Cells) > columns {
+			columns = len(row.Cells)
+		}
+	}
+	if columns == 0 {
+		return
+	}
+
+	for i, row := range table.Rows {
+		cells := make([]string, columns)
+		for j, cell := range row.Cells {
+			cells[j] = renderTableCell(cell)
+		}
+		fmt.Fprintf(builder, "| %s |\n", strings.Join(cells, " | "))
+
+		if i == 0 {
+			separators := make([]string, columns)
+			for j := range separators {
+				separators[j] = "---"
+			}
+			fmt.Fprintf(builder, "| %s |\n", strings.Join(separators, " | "))
+		}
+	}
+}
+
+// renderTableCell renders the paragraphs of a cell on a single line
+func renderTableCell(cell TableCell) string {
+	parts := make([]string, 0, len(cell.Blocks))
+	for _, block := range cell.Blocks {
+		if block.Paragraph == nil {
+			continue
+		}
+		if text := renderInline(block.Paragraph.Runs); text != "" {
+			parts = append(parts, text)
+		}
+	}
+	return strings.ReplaceAll(strings.Join(parts, "<br>"), "|", `\|`)
+}
+
+// mergeRuns joins adjacent runs with the same Markdown formatting. Docs splits runs on any style
+// change, such as color or size, which would otherwise render as "**ab****cd**".
+func mergeRuns(runs []TextRun) []TextRun {
+	merged := make([]TextRun, 0, len(runs))
+	for _, run := range runs {
+		if n := len(merged); n > 0 {
+			last := &merged[n-1]
+			if last.Bold == run.Bold && last.Italic == run.Italic && last.Code == run.Code && last.Link == run.Link {
+				last.Text += run.Text
+				last.EndIndex = run.EndIndex
+				continue
+			}
+		}
+		merged = append(merged, run)
+	}
+	return merged
+}
+
+// codeSpan wraps text in a code span, using a backtick fence longer than any backtick run in text
+func codeSpan(text string) string {
+	longest, current := 0, 0
+	for _, r := range text {
+		if r == '`' {
+			current++
+			longest = max(longest, current)
+		} else {
+			current = 0
+		}
+	}
+
+	fence := strings.Repeat("`", longest+1)
+	if strings.HasPrefix(text, "`") || strings.HasSuffix(text, "`") {
+		// A space keeps a backtick at the edge from joining the fence
+		text = " " + text + " "
+	}
+	return fence + text + fence
+}
+
+// renderInline renders text runs with bold, italic, code and link formatting
+func renderInline(runs []TextRun) string {
+	var builder strings.Builder
+
+	for _, run := range mergeRuns(runs) {
+		text := strings.ReplaceAll(run.Text, "\n", "")
+		text = strings.ReplaceAll(text, "\v", " ") // Docs uses vertical tab for soft line breaks
+
+		// Keep surrounding whitespace outside of the formatting markers
+		trimmed := strings.TrimSpace(text)
+		if trimmed == "" {
+			builder.WriteString(text)
+			continue
+		}
+		leading := text[:strings.Index(text, trimmed)]
+		trailing := text[len(leading)+len(trimmed):]
+
+		if run.Code {
+			trimmed = codeSpan(trimmed)
+		} else {
+			trimmed = markdownEscaper.Replace(trimmed)
+			if run.Bold && run.Italic {
+				trimmed = "***" + trimmed + "***"
+			} else if run.Bold {
+				trimmed = "**" + trimmed + "**"
+			} else if run.Italic {
+				trimmed = "*" + trimmed + "*"
+			}
+		}
+
+		if run.Link != "" {
+			trimmed = fmt.Sprintf("[%s](%s)", trimmed, run.Link)
+		}
+
+		builder.WriteString(leading)
+		builder.WriteString(trimmed)
+		builder.WriteString(trailing)
+	}
+
+	return strings.TrimSpace(builder.String())
+}
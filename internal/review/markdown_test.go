@@ -0,0 +1,181 @@
+package review
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestRenderMarkdown(t *testing.T) {
+	doc := &Document{
+		Sections: []*Section{
+			{
+				Blocks: []Block{
+					{Kind: BlockParagraph, Paragraph: &Paragraph{Style: StyleTitle, Runs: []TextRun{{Text: "Design Doc\n"}}}},
+				},
+			},
+			{
+				Level:   1,
+				Heading: &Paragraph{Style: StyleHeading1, Runs: []TextRun{{Text: "Overview\n"}}},
+				Blocks: []Block{
+					{Kind: BlockParagraph, Paragraph: &Paragraph{Runs: []TextRun{
+						{Text: "This is "},
+						{Text: "important ", Bold: true},
+						{Text: "and "},
+						{Text: "subtle", Italic: true},
+						{Text: ". Run "},
+						{Text: "go test", Code: true},
+						{Text: " or see "},
+						{Text: "docs", Link: "https://example.com"},
+						{Text: ".\n"},
+					}}},
+					{Kind: BlockListItem, Paragraph: &Paragraph{Runs: []TextRun{{Text: "first\n"}}, List: &ListItem{}}},
+					{Kind: BlockListItem, Paragraph: &Paragraph{Runs: []TextRun{{Text: "nested\n"}}, List: &ListItem{NestingLevel: 1, Ordered: true}}},
+				},
+				Sections: []*Section{
+					{
+						Level:   2,
+						Heading: &Paragraph{Style: StyleHeading2, Runs: []TextRun{{Text: "Data\n"}}},
+						Blocks: []Block{
+							{Kind: BlockTable, Table: &Table{Rows: []TableRow{
+								{Cells: []TableCell{
+									{Blocks: []Block{{Kind: BlockParagraph, Paragraph: &Paragraph{Runs: []TextRun{{Text: "Name\n"}}}}}},
+									{Blocks: []Block{{Kind: BlockParagraph, Paragraph: &Paragraph{Runs: []TextRun{{Text: "Value\n"}}}}}},
+								}},
+								{Cells: []TableCell{
+									{Blocks: []Block{{Kind: BlockParagraph, Paragraph: &Paragraph{Runs: []TextRun{{Text: "a|b\n"}}}}}},
+								}},
+							}}},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	want := `# Design Doc
+
+# Overview
+
+This is **important** and *subtle*. Run ` + "`go test`" + ` or see [docs](https://example.com).
+
+- first
+    1. nested
+
+## Data
+
+| Name | Value |
+| --- | --- |
+| a\|b |  |
+`
+
+	if diff := cmp.Diff(want, RenderMarkdown(doc)); diff != "" {
+		t.Errorf("RenderMarkdown() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestRenderInline_EscapesMarkdown(t *testing.T) {
+	got := renderInline([]TextRun{{Text: "a*b_c\n"}})
+	if diff := cmp.Diff(`a\*b\_c`, got); diff != "" {
+		t.Errorf("renderInline() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestRenderInline_MergesRuns(t *testing.T) {
+	tests := []struct {
+		name string
+		runs []TextRun
+		want string
+	}{
+		{
+			name: "adjacent bold runs",
+			runs: []TextRun{{Text: "ab", Bold: true}, {Text: "cd", Bold: true}, {Text: "\n"}},
+			want: "**abcd**",
+		},
+		{
+			name: "different formatting",
+			runs: []TextRun{{Text: "ab", Bold: true}, {Text: "cd", Italic: true}},
+			want: "**ab***cd*",
+		},
+		{
+			name: "adjacent links",
+			runs: []TextRun{{Text: "do", Link: "https://example.com"}, {Text: "cs", Link: "https://example.com"}},
+			want: "[docs](https://example.com)",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if diff := cmp.Diff(tt.want, renderInline(tt.runs)); diff != "" {
+				t.Errorf("renderInline() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestCodeSpan(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{text: "go test", want: "`go test`"},
+		{text: "use `x` here", want: "``use `x` here``"},
+		{text: "``", want: "``` `` ```"},
+	}
+
+	for _, tt := range tests {
+		if diff := cmp.Diff(tt.want, codeSpan(tt.text)); diff != "" {
+			t.Errorf("codeSpan(%q) mismatch (-want +got):\n%s", tt.text, diff)
+		}
+	}
+}
+
+func TestRenderParagraph_EscapesBlockMarkers(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{text: "# not a heading", want: `\# not a heading`},
+		{text: "1. not a list", want: `1\. not a list`},
+		{text: "> not a quote", want: `\> not a quote`},
+		{text: "- not a bullet", want: `\- not a bullet`},
+		{text: "#hashtag", want: "#hashtag"},
+		{text: "2024. year", want: `2024\. year`},
+		{text: "-1 degree", want: "-1 degree"},
+	}
+
+	for _, tt := range tests {
+		got := renderParagraph(&Paragraph{Runs: []TextRun{{Text: tt.text + "\n"}}})
+		if diff := cmp.Diff(tt.want, got); diff != "" {
+			t.Errorf("renderParagraph(%q) mismatch (-want +got):\n%s", tt.text, diff)
+		}
+	}
+}
+
+func TestDocument_Render(t *testing.T) {
+	doc := &Document{ID: "doc-1", Title: "Title", Content: "plain text"}
+
+	tests := []struct {
+		name    string
+		format  Format
+		want    string
+		wantErr bool
+	}{
+		{name: "default is text", format: "", want: "plain text"},
+		{name: "text", format: FormatText, want: "plain text"},
+		{name: "json", format: FormatJSON, want: "{\n  \"id\": \"doc-1\",\n  \"title\": \"Title\",\n  \"sections\": null,\n  \"lines\": null\n}"},
+		{name: "unknown", format: "html", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := doc.Render(tt.format)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Render() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("Render() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,173 @@
+package review
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+
+	"google.golang.org/api/drive/v3"
+)
+
+// plainTextMimeType is the export format used to compare revisions
+const plainTextMimeType = "text/plain"
+
+// maxListedRevisions is the number of recent revisions a RevisionDiff lists to pick another base from
+const maxListedRevisions = 10
+
+// Revision is a stored revision of a Google Doc
+type Revision struct {
+	ID                string `json:"id"`
+	ModifiedTime      string `json:"modified_time"`
+	LastModifyingUser string `json:"last_modifying_user,omitempty"`
+	exportLink        string // URL of the plain text export of the revision
+}
+
+// RevisionDiff is the paragraph-level difference between a past revision and the head of a document
+type RevisionDiff struct {
+	DocumentID      string             `json:"document_id"`
+	BaseRevision    *Revision          `json:"base_revision"`
+	Changes         []*ParagraphChange `json:"changes"`
+	ChangedSections []string           `json:"changed_sections"` // Headings of the sections with changes, "" for text before the first heading
+	Revisions       []*Revision        `json:"revisions"`        // Most recent revisions, oldest first, to pick another base from
+	TotalRevisions  int                `json:"total_revisions"`  // Number of revisions of the document, including those not listed
+}
+
+// ListRevisions returns the revisions of a document, oldest first
+func (f *GoogleDocFetcher) ListRevisions(ctx context.Context, documentID string) ([]*Revision, error) {
+	revisions := make([]*Revision, 0)
+	err := f.driveService.Revisions.List(documentID).
+		Fields("nextPageToken,revisions(id,modifiedTime,exportLinks,lastModifyingUser(displayName))").
+		Pages(ctx, func(list *drive.RevisionList) error {
+			for _, r := range list.Revisions {
+				revision := &Revision{
+					ID:           r.Id,
+					ModifiedTime: r.ModifiedTime,
+					exportLink:   r.ExportLinks[plainTextMimeType],
+				}
+				if r.LastModifyingUser != nil {
+					revision.LastModifyingUser = r.LastModifyingUser.DisplayName
+				}
+				revisions = append(revisions, revision)
+			}
+			return nil
+		})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list revisions: %w", err)
+	}
+
+	return revisions, nil
+}
+
+// DiffRevision compares a past revision with the head of a document paragraph by paragraph.
+// Both versions are exported as plain text so they are formatted the same way.
+// An empty revisionID compares against the revision before the head.
+func (f *GoogleDocFetcher) DiffRevision(ctx context.Context, documentID, revisionID string) (*RevisionDiff, error) {
+	revisions, err := f.ListRevisions(ctx, documentID)
+	if err != nil {
+		return nil, err
+	}
+
+	base, err := selectRevision(revisions, revisionID)
+	if err != nil {
+		return nil, err
+	}
+	if base.exportLink == "" {
+		return nil, fmt.Errorf("revision %s cannot be exported as plain text", base.ID)
+	}
+
+	baseText, err := f.download(ctx, base.exportLink)
+	if err != nil {
+		return nil, fmt.Errorf("failed to export revision %s: %w", base.ID, err)
+	}
+
+	headText, err := f.exportHead(ctx, documentID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Section headings come from the structured head document
+	head, err := f.FetchDocumentByID(ctx, documentID, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := diffParagraphs(splitParagraphs(baseText), splitParagraphs(headText), sectionTitles(head.Sections))
+
+	return &RevisionDiff{
+		DocumentID:      documentID,
+		BaseRevision:    base,
+		Changes:         changes,
+		ChangedSections: ChangedSections(changes),
+		Revisions:       revisions[max(0, len(revisions)-maxListedRevisions):],
+		TotalRevisions:  len(revisions),
+	}, nil
+}
+
+// selectRevision returns the revision with the given ID, or the revision before the head
+func selectRevision(revisions []*Revision, revisionID string) (*Revision, error) {
+	if revisionID == "" {
+		if len(revisions) < 2 {
+			return nil, fmt.Errorf("document has no revision before the head")
+		}
+		return revisions[len(revisions)-2], nil
+	}
+
+	for _, revision := range revisions {
+		if revision.ID == revisionID {
+			return revision, nil
+		}
+	}
+	return nil, fmt.Errorf("revision not found: %s", revisionID)
+}
+
+// sectionTitles returns the headings of sections and their subsections in document order
+func sectionTitles(sections []*Section) []string {
+	titles := make([]string, 0)
+	for _, section := range sections {
+		if section.Heading != nil {
+			titles = append(titles, section.Title())
+		}
+		titles = append(titles, sectionTitles(section.Sections)...)
+	}
+	return titles
+}
+
+// exportHead exports the current content of a document as plain text
+func (f *GoogleDocFetcher) exportHead(ctx context.Context, documentID string) (string, error) {
+	resp, err := f.driveService.Files.Export(documentID, plainTextMimeType).Context(ctx).Download()
+	if err != nil {
+		return "", fmt.Errorf("failed to export document: %w", err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("failed to read export: %w", err)
+	}
+	return string(data), nil
+}
+
+// download fetches an export link with the authorized client
+func (f *GoogleDocFetcher) download(ctx context.Context, url string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("unexpected status: %s", resp.Status)
+	}
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("failed to read export: %w", err)
+	}
+	return string(data), nil
+}
@@ -0,0 +1,161 @@
+package review
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/api/docs/v1"
+	"google.golang.org/api/drive/v3"
+	"google.golang.org/api/option"
+)
+
+// newTestRevisionFetcher creates a GoogleDocFetcher backed by a server with revisionCount revisions
+// of doc-1, listed two per page. The export of rev-broken fails.
+func newTestRevisionFetcher(t *testing.T, revisionCount int) *GoogleDocFetcher {
+	t.Helper()
+
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	mux.HandleFunc("GET /drive/v3/files/doc-1/revisions", func(w http.ResponseWriter, r *http.Request) {
+		start := 0
+		if token := r.URL.Query().Get("pageToken"); token != "" {
+			fmt.Sscanf(token, "page-%d", &start)
+		}
+
+		list := &drive.RevisionList{}
+		for i := start; i < min(start+2, revisionCount); i++ {
+			id := fmt.Sprintf("rev-%d", i+1)
+			if i == 0 {
+				id = "rev-broken"
+			}
+			list.Revisions = append(list.Revisions, &drive.Revision{
+				Id:           id,
+				ModifiedTime: fmt.Sprintf("2025-01-%02dT00:00:00Z", i+1),
+				ExportLinks:  map[string]string{plainTextMimeType: server.URL + "/export/" + id},
+			})
+		}
+		if start+2 < revisionCount {
+			list.NextPageToken = fmt.Sprintf("page-%d", start+2)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(list)
+	})
+	mux.HandleFunc("GET /export/{id}", func(w http.ResponseWriter, r *http.Request) {
+		if r.PathValue("id") == "rev-broken" {
+			http.Error(w, "gone", http.StatusGone)
+			return
+		}
+		w.Write([]byte("\ufeff概要\r\n古い説明\r\n"))
+	})
+	mux.HandleFunc("GET /drive/v3/files/doc-1/export", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("mimeType") != plainTextMimeType {
+			http.Error(w, "unsupported export", http.StatusBadRequest)
+			return
+		}
+		w.Write([]byte("\ufeff概要\r\n新しい説明\r\n"))
+	})
+	mux.HandleFunc("GET /v1/documents/doc-1", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(&docs.Document{
+			DocumentId: "doc-1",
+			Body: &docs.Body{Content: []*docs.StructuralElement{
+				{
+					StartIndex: 1,
+					EndIndex:   4,
+					Paragraph: &docs.Paragraph{
+						ParagraphStyle: &docs.ParagraphStyle{NamedStyleType: "HEADING_1"},
+						Elements:       []*docs.ParagraphElement{{StartIndex: 1, EndIndex: 4, TextRun: &docs.TextRun{Content: "概要\n"}}},
+					},
+				},
+				{
+					StartIndex: 4,
+					EndIndex:   10,
+					Paragraph: &docs.Paragraph{
+						Elements: []*docs.ParagraphElement{{StartIndex: 4, EndIndex: 10, TextRun: &docs.TextRun{Content: "新しい説明\n"}}},
+					},
+				},
+			}},
+		})
+	})
+
+	ctx := context.Background()
+	docsService, err := docs.NewService(ctx, option.WithHTTPClient(server.Client()), option.WithEndpoint(server.URL+"/"))
+	if err != nil {
+		t.Fatalf("failed to create Docs service: %v", err)
+	}
+	driveService, err := drive.NewService(ctx, option.WithHTTPClient(server.Client()), option.WithEndpoint(server.URL+"/drive/v3/"))
+	if err != nil {
+		t.Fatalf("failed to create Drive service: %v", err)
+	}
+
+	return NewGoogleDocFetcherWithServices(server.Client(), docsService, driveService)
+}
+
+func TestListRevisions_FollowsAllPages(t *testing.T) {
+	fetcher := newTestRevisionFetcher(t, 5)
+
+	revisions, err := fetcher.ListRevisions(context.Background(), "doc-1")
+	if err != nil {
+		t.Fatalf("ListRevisions() error = %v", err)
+	}
+
+	ids := make([]string, 0, len(revisions))
+	for _, revision := range revisions {
+		ids = append(ids, revision.ID)
+	}
+	if diff := cmp.Diff([]string{"rev-broken", "rev-2", "rev-3", "rev-4", "rev-5"}, ids); diff != "" {
+		t.Errorf("ListRevisions() mismatch (-want +got):\n%s", diff)
+	}
+	if !strings.HasSuffix(revisions[1].exportLink, "/export/rev-2") {
+		t.Errorf("exportLink = %q, want the plain text export link", revisions[1].exportLink)
+	}
+}
+
+func TestDiffRevision(t *testing.T) {
+	fetcher := newTestRevisionFetcher(t, 15)
+	ctx := context.Background()
+
+	diff, err := fetcher.DiffRevision(ctx, "doc-1", "")
+	if err != nil {
+		t.Fatalf("DiffRevision() error = %v", err)
+	}
+
+	if diff.BaseRevision.ID != "rev-14" {
+		t.Errorf("BaseRevision = %q, want the revision before the head", diff.BaseRevision.ID)
+	}
+	want := []*ParagraphChange{
+		{Kind: ChangeModified, Section: "概要", OldText: "古い説明", NewText: "新しい説明", OldLine: 2, NewLine: 2},
+	}
+	if d := cmp.Diff(want, diff.Changes); d != "" {
+		t.Errorf("Changes mismatch (-want +got):\n%s", d)
+	}
+	if d := cmp.Diff([]string{"概要"}, diff.ChangedSections); d != "" {
+		t.Errorf("ChangedSections mismatch (-want +got):\n%s", d)
+	}
+
+	// Only the most recent revisions are listed
+	if len(diff.Revisions) != maxListedRevisions || diff.TotalRevisions != 15 {
+		t.Errorf("listed %d of %d revisions, want %d of 15", len(diff.Revisions), diff.TotalRevisions, maxListedRevisions)
+	}
+	if last := diff.Revisions[len(diff.Revisions)-1].ID; last != "rev-15" {
+		t.Errorf("last listed revision = %q, want the head", last)
+	}
+
+	// A failed export is reported with its status
+	if _, err := fetcher.DiffRevision(ctx, "doc-1", "rev-broken"); err == nil || !strings.Contains(err.Error(), "410") {
+		t.Errorf("DiffRevision() error = %v, want the export status", err)
+	}
+
+	if _, err := fetcher.DiffRevision(ctx, "doc-1", "rev-unknown"); err == nil {
+		t.Error("DiffRevision() with an unknown revision should return error")
+	}
+}
@@ -0,0 +1,371 @@
+package review
+
+import (
+	"strings"
+
+	"google.golang.org/api/docs/v1"
+)
+
+// NamedStyle is the Docs named paragraph style (e.g. NORMAL_TEXT, HEADING_1)
+type NamedStyle string
+
+const (
+	StyleNormalText NamedStyle = "NORMAL_TEXT"
+	StyleTitle      NamedStyle = "TITLE"
+	StyleSubtitle   NamedStyle = "SUBTITLE"
+	StyleHeading1   NamedStyle = "HEADING_1"
+	StyleHeading2   NamedStyle = "HEADING_2"
+	StyleHeading3   NamedStyle = "HEADING_3"
+	StyleHeading4   NamedStyle = "HEADING_4"
+	StyleHeading5   NamedStyle = "HEADING_5"
+	StyleHeading6   NamedStyle = "HEADING_6"
+)
+
+// HeadingLevel returns 1-6 for heading styles and 0 otherwise
+func (s NamedStyle) HeadingLevel() int {
+	switch s {
+	case StyleHeading1:
+		return 1
+	case StyleHeading2:
+		return 2
+	case StyleHeading3:
+		return 3
+	case StyleHeading4:
+		return 4
+	case StyleHeading5:
+		return 5
+	case StyleHeading6:
+		return 6
+	default:
+		return 0
+	}
+}
+
+// BlockKind identifies the type of a Block
+type BlockKind string
+
+const (
+	BlockParagraph BlockKind = "paragraph"
+	BlockListItem  BlockKind = "list_item"
+	BlockTable     BlockKind = "table"
+)
+
+// Section is a heading together with everything up to the next heading of the same or higher level.
+// Content before the first heading is kept in a section with Level 0 and no Heading.
+type Section struct {
+	Level      int        `json:"level"`
+	Heading    *Paragraph `json:"heading,omitempty"`
+	Blocks     []Block    `json:"blocks"`
+	Sections   []*Section `json:"sections,omitempty"`
+	StartIndex int64      `json:"start_index"`
+	EndIndex   int64      `json:"end_index"`
+}
+
+// Title returns the heading text of the section
+func (s *Section) Title() string {
+	if s.Heading == nil {
+		return ""
+	}
+	return s.Heading.Text
+}
+
+// Block is a body element inside a section. Exactly one of Paragraph or Table is set.
+type Block struct {
+	Kind      BlockKind  `json:"kind"`
+	Paragraph *Paragraph `json:"paragraph,omitempty"`
+	Table     *Table     `json:"table,omitempty"`
+}
+
+// Paragraph is a paragraph with its named style and formatted text runs
+type Paragraph struct {
+	Style      NamedStyle `json:"style"`
+	Text       string     `json:"text"`
+	Runs       []TextRun  `json:"runs"`
+	List       *ListItem  `json:"list,omitempty"`
+	StartIndex int64      `json:"start_index"`
+	EndIndex   int64      `json:"end_index"`
+}
+
+// ListItem describes the list membership of a paragraph
+type ListItem struct {
+	ListID       string `json:"list_id"`
+	NestingLevel int    `json:"nesting_level"`
+	Ordered      bool   `json:"ordered"`
+}
+
+// TextRun is a run of text sharing the same formatting
+type TextRun struct {
+	Text       string `json:"text"`
+	Bold       bool   `json:"bold,omitempty"`
+	Italic     bool   `json:"italic,omitempty"`
+	Code       bool   `json:"code,omitempty"`
+	Link       string `json:"link,omitempty"`
+	StartIndex int64  `json:"start_index"`
+	EndIndex   int64  `json:"end_index"`
+}
+
+// Table is a table with its rows and cells
+type Table struct {
+	Rows       []TableRow `json:"rows"`
+	StartIndex int64      `json:"start_index"`
+	EndIndex   int64      `json:"end_index"`
+}
+
+// TableRow is a row of a table. Header is set when the row is pinned as a header row in Docs.
+type TableRow struct {
+	Header     bool        `json:"header,omitempty"`
+	Cells      []TableCell `json:"cells"`
+	StartIndex int64       `json:"start_index"`
+	EndIndex   int64       `json:"end_index"`
+}
+
+// TableCell is a cell of a table containing its own blocks
+type TableCell struct {
+	Blocks     []Block `json:"blocks"`
+	StartIndex int64   `json:"start_index"`
+	EndIndex   int64   `json:"end_index"`
+}
+
+// Text returns the plain text of the cell with paragraphs joined by spaces
+func (c TableCell) Text() string {
+	parts := make([]string, 0, len(c.Blocks))
+	for _, block := range c.Blocks {
+		if block.Paragraph != nil && block.Paragraph.Text != "" {
+			parts = append(parts, block.Paragraph.Text)
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+// monospaceFonts are font families treated as inline code
+var monospaceFonts = map[string]bool{
+	"Courier New":     true,
+	"Consolas":        true,
+	"Roboto Mono":     true,
+	"Source Code Pro": true,
+	"Inconsolata":     true,
+	"Ubuntu Mono":     true,
+}
+
+// buildSections converts the body of a Google Doc into a section tree
+func buildSections(doc *docs.Document) []*Section {
+	if doc.Body == nil {
+		return nil
+	}
+
+	var (
+		sections []*Section
+		stack    []*Section
+	)
+
+	for _, element := range doc.Body.Content {
+		block, ok := buildBlock(doc, element)
+		if !ok {
+			continue
+		}
+
+		if block.Paragraph != nil && block.Paragraph.List == nil {
+			if level := block.Paragraph.Style.HeadingLevel(); level > 0 {
+				section := &Section{
+					Level:      level,
+					Heading:    block.Paragraph,
+					Blocks:     make([]Block, 0),
+					StartIndex: block.Paragraph.StartIndex,
+					EndIndex:   block.Paragraph.EndIndex,
+				}
+
+				// Close sections at the same or a deeper level
+				for len(stack) > 0 && stack[len(stack)-1].Level >= level {
+					stack = stack[:len(stack)-1]
+				}
+
+				if len(stack) > 0 && stack[len(stack)-1].Level > 0 {
+					parent := stack[len(stack)-1]
+					parent.Sections = append(parent.Sections, section)
+				} else {
+					sections = append(sections, section)
+				}
+				stack = append(stack, section)
+				continue
+			}
+		}
+
+		if len(stack) == 0 {
+			// Content before the first heading
+			preamble := &Section{
+				Blocks:     make([]Block, 0),
+				StartIndex: blockStartIndex(block),
+			}
+			sections = append(sections, preamble)
+			stack = append(stack, preamble)
+		}
+
+		current := stack[len(stack)-1]
+		current.Blocks = append(current.Blocks, block)
+	}
+
+	for _, section := range sections {
+		updateSectionEndIndex(section)
+	}
+
+	return sections
+}
+
+// updateSectionEndIndex sets EndIndex of a section to the end of its last block or subsection
+func updateSectionEndIndex(section *Section) int64 {
+	for _, block := range section.Blocks {
+		if end := blockEndIndex(block); end > section.EndIndex {
+			section.EndIndex = end
+		}
+	}
+	for _, sub := range section.Sections {
+		if end := updateSectionEndIndex(sub); end > section.EndIndex {
+			section.EndIndex = end
+		}
+	}
+	return section.EndIndex
+}
+
+// buildBlock converts a structural element into a Block. Section breaks and tables of contents are skipped.
+func buildBlock(doc *docs.Document, element *docs.StructuralElement) (Block, bool) {
+	switch {
+	case element.Paragraph != nil:
+		paragraph := buildParagraph(doc, element)
+		if paragraph.List != nil {
+			return Block{Kind: BlockListItem, Paragraph: paragraph}, true
+		}
+		return Block{Kind: BlockParagraph, Paragraph: paragraph}, true
+	case element.Table != nil:
+		return Block{Kind: BlockTable, Table: buildTable(doc, element)}, true
+	default:
+		return Block{}, false
+	}
+}
+
+// buildParagraph converts a paragraph element into a Paragraph
+func buildParagraph(doc *docs.Document, element *docs.StructuralElement) *Paragraph {
+	p := element.Paragraph
+	paragraph := &Paragraph{
+		Style:      StyleNormalText,
+		Runs:       make([]TextRun, 0, len(p.Elements)),
+		StartIndex: element.StartIndex,
+		EndIndex:   element.EndIndex,
+	}
+
+	if p.ParagraphStyle != nil && p.ParagraphStyle.NamedStyleType != "" {
+		paragraph.Style = NamedStyle(p.ParagraphStyle.NamedStyleType)
+	}
+
+	var text strings.Builder
+	for _, elem := range p.Elements {
+		if elem.TextRun == nil || elem.TextRun.Content == "" {
+			continue
+		}
+
+		run := TextRun{
+			Text:       elem.TextRun.Content,
+			StartIndex: elem.StartIndex,
+			EndIndex:   elem.EndIndex,
+		}
+		if style := elem.TextRun.TextStyle; style != nil {
+			run.Bold = style.Bold
+			run.Italic = style.Italic
+			if style.Link != nil {
+				run.Link = style.Link.Url
+			}
+			if style.WeightedFontFamily != nil {
+				run.Code = monospaceFonts[style.WeightedFontFamily.FontFamily]
+			}
+		}
+
+		paragraph.Runs = append(paragraph.Runs, run)
+		text.WriteString(elem.TextRun.Content)
+	}
+	paragraph.Text = strings.TrimRight(text.String(), "\n")
+
+	if p.Bullet != nil {
+		paragraph.List = &ListItem{
+			ListID:       p.Bullet.ListId,
+			NestingLevel: int(p.Bullet.NestingLevel),
+			Ordered:      isOrderedList(doc, p.Bullet.ListId, p.Bullet.NestingLevel),
+		}
+	}
+
+	return paragraph
+}
+
+// isOrderedList reports whether the list level uses numbered glyphs instead of bullet symbols
+func isOrderedList(doc *docs.Document, listID string, nestingLevel int64) bool {
+	list, ok := doc.Lists[listID]
+	if !ok || list.ListProperties == nil {
+		return false
+	}
+
+	levels := list.ListProperties.NestingLevels
+	if nestingLevel < 0 || int(nestingLevel) >= len(levels) {
+		return false
+	}
+
+	level := levels[nestingLevel]
+	if level.GlyphSymbol != "" {
+		return false
+	}
+	switch level.GlyphType {
+	case "", "GLYPH_TYPE_UNSPECIFIED", "NONE":
+		return false
+	default:
+		return true
+	}
+}
+
+// buildTable converts a table element into a Table
+func buildTable(doc *docs.Document, element *docs.StructuralElement) *Table {
+	table := &Table{
+		Rows:       make([]TableRow, 0, len(element.Table.TableRows)),
+		StartIndex: element.StartIndex,
+		EndIndex:   element.EndIndex,
+	}
+
+	for _, row := range element.Table.TableRows {
+		tableRow := TableRow{
+			Header:     row.TableRowStyle != nil && row.TableRowStyle.TableHeader,
+			Cells:      make([]TableCell, 0, len(row.TableCells)),
+			StartIndex: row.StartIndex,
+			EndIndex:   row.EndIndex,
+		}
+
+		for _, cell := range row.TableCells {
+			tableCell := TableCell{
+				Blocks:     make([]Block, 0, len(cell.Content)),
+				StartIndex: cell.StartIndex,
+				EndIndex:   cell.EndIndex,
+			}
+			for _, content := range cell.Content {
+				if block, ok := buildBlock(doc, content); ok {
+					tableCell.Blocks = append(tableCell.Blocks, block)
+				}
+			}
+			tableRow.Cells = append(tableRow.Cells, tableCell)
+		}
+
+		table.Rows = append(table.Rows, tableRow)
+	}
+
+	return table
+}
+
+// blockStartIndex returns the start index of a block
+func blockStartIndex(block Block) int64 {
+	if block.Table != nil {
+		return block.Table.StartIndex
+	}
+	return block.Paragraph.StartIndex
+}
+
+// blockEndIndex returns the end index of a block
+func blockEndIndex(block Block) int64 {
+	if block.Table != nil {
+		return block.Table.EndIndex
+	}
+	return block.Paragraph.EndIndex
+}
@@ -0,0 +1,135 @@
+package review
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/api/docs/v1"
+)
+
+// paragraphElement builds a paragraph structural element for tests
+func paragraphElement(start int64, style string, text string) *docs.StructuralElement {
+	end := start + int64(len([]rune(text)))
+	return &docs.StructuralElement{
+		StartIndex: start,
+		EndIndex:   end,
+		Paragraph: &docs.Paragraph{
+			ParagraphStyle: &docs.ParagraphStyle{NamedStyleType: style},
+			Elements: []*docs.ParagraphElement{
+				{StartIndex: start, EndIndex: end, TextRun: &docs.TextRun{Content: text}},
+			},
+		},
+	}
+}
+
+func TestBuildSections(t *testing.T) {
+	bullet := paragraphElement(30, "NORMAL_TEXT", "step\n")
+	bullet.Paragraph.Bullet = &docs.Bullet{ListId: "list-1", NestingLevel: 1}
+
+	doc := &docs.Document{
+		Lists: map[string]docs.List{
+			"list-1": {
+				ListProperties: &docs.ListProperties{
+					NestingLevels: []*docs.NestingLevel{
+						{GlyphSymbol: "●"},
+						{GlyphType: "DECIMAL"},
+					},
+				},
+			},
+		},
+		Body: &docs.Body{
+			Content: []*docs.StructuralElement{
+				{EndIndex: 1, SectionBreak: &docs.SectionBreak{}},
+				paragraphElement(1, "TITLE", "Doc\n"),
+				paragraphElement(5, "HEADING_1", "Intro\n"),
+				paragraphElement(11, "NORMAL_TEXT", "Hello world\n"),
+				paragraphElement(23, "HEADING_3", "Deep\n"),
+				bullet,
+				paragraphElement(35, "HEADING_1", "Next\n"),
+				{
+					StartIndex: 40,
+					EndIndex:   50,
+					Table: &docs.Table{
+						TableRows: []*docs.TableRow{
+							{
+								StartIndex:    41,
+								EndIndex:      49,
+								TableRowStyle: &docs.TableRowStyle{TableHeader: true},
+								TableCells: []*docs.TableCell{
+									{StartIndex: 42, EndIndex: 45, Content: []*docs.StructuralElement{paragraphElement(43, "NORMAL_TEXT", "a\n")}},
+									{StartIndex: 45, EndIndex: 48, Content: []*docs.StructuralElement{paragraphElement(46, "NORMAL_TEXT", "b\n")}},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	sections := buildSections(doc)
+
+	if len(sections) != 3 {
+		t.Fatalf("buildSections() returned %d top-level sections, want 3", len(sections))
+	}
+
+	preamble := sections[0]
+	if preamble.Level != 0 || preamble.Heading != nil {
+		t.Errorf("preamble = level %d heading %v, want level 0 without heading", preamble.Level, preamble.Heading)
+	}
+	if len(preamble.Blocks) != 1 || preamble.Blocks[0].Paragraph.Style != StyleTitle {
+		t.Errorf("preamble blocks = %+v, want a single TITLE paragraph", preamble.Blocks)
+	}
+
+	intro := sections[1]
+	if diff := cmp.Diff("Intro", intro.Title()); diff != "" {
+		t.Errorf("intro title mismatch (-want +got):\n%s", diff)
+	}
+	if intro.StartIndex != 5 || intro.EndIndex != 35 {
+		t.Errorf("intro range = [%d, %d), want [5, 35)", intro.StartIndex, intro.EndIndex)
+	}
+	if len(intro.Sections) != 1 || intro.Sections[0].Level != 3 {
+		t.Fatalf("intro subsections = %+v, want a single level 3 section", intro.Sections)
+	}
+
+	deep := intro.Sections[0]
+	if len(deep.Blocks) != 1 || deep.Blocks[0].Kind != BlockListItem {
+		t.Fatalf("deep blocks = %+v, want a single list item", deep.Blocks)
+	}
+	wantList := &ListItem{ListID: "list-1", NestingLevel: 1, Ordered: true}
+	if diff := cmp.Diff(wantList, deep.Blocks[0].Paragraph.List); diff != "" {
+		t.Errorf("list item mismatch (-want +got):\n%s", diff)
+	}
+
+	next := sections[2]
+	if len(next.Blocks) != 1 || next.Blocks[0].Kind != BlockTable {
+		t.Fatalf("next blocks = %+v, want a single table", next.Blocks)
+	}
+	row := next.Blocks[0].Table.Rows[0]
+	if !row.Header {
+		t.Error("table row should be marked as header")
+	}
+	if got := []string{row.Cells[0].Text(), row.Cells[1].Text()}; !cmp.Equal(got, []string{"a", "b"}) {
+		t.Errorf("cell texts = %v, want [a b]", got)
+	}
+}
+
+func TestNamedStyle_HeadingLevel(t *testing.T) {
+	tests := []struct {
+		style NamedStyle
+		want  int
+	}{
+		{StyleHeading1, 1},
+		{StyleHeading6, 6},
+		{StyleTitle, 0},
+		{StyleNormalText, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.style), func(t *testing.T) {
+			if got := tt.style.HeadingLevel(); got != tt.want {
+				t.Errorf("HeadingLevel() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,151 @@
+package review
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"google.golang.org/api/docs/v1"
+)
+
+// SuggestionsView selects how pending suggestions are reflected in a fetched document
+type SuggestionsView string
+
+const (
+	SuggestionsViewDefault  SuggestionsView = ""         // The Docs default for the current user's access
+	SuggestionsViewInline   SuggestionsView = "inline"   // Suggested insertions and deletions are both in the text
+	SuggestionsViewAccepted SuggestionsView = "accepted" // The text as it reads once every suggestion is accepted
+	SuggestionsViewRejected SuggestionsView = "rejected" // The text as it reads once every suggestion is rejected
+)
+
+// mode returns the Docs API SuggestionsViewMode for the view
+func (v SuggestionsView) mode() (string, error) {
+	switch v {
+	case SuggestionsViewDefault:
+		return "DEFAULT_FOR_CURRENT_ACCESS", nil
+	case SuggestionsViewInline:
+		return "SUGGESTIONS_INLINE", nil
+	case SuggestionsViewAccepted:
+		return "PREVIEW_SUGGESTIONS_ACCEPTED", nil
+	case SuggestionsViewRejected:
+		return "PREVIEW_WITHOUT_SUGGESTIONS", nil
+	default:
+		return "", fmt.Errorf("unsupported suggestions view: %s", v)
+	}
+}
+
+// Suggestion is a pending suggestion in a document.
+// The Docs API does not report who made a suggestion, so no author is available.
+type Suggestion struct {
+	ID           string `json:"id"`
+	InsertedText string `json:"inserted_text,omitempty"`
+	DeletedText  string `json:"deleted_text,omitempty"`
+	StyleChanged bool   `json:"style_changed,omitempty"` // The suggestion changes text or paragraph formatting
+	StartIndex   int64  `json:"start_index"`             // Start of the affected text in the inline view
+	EndIndex     int64  `json:"end_index"`               // End of the affected text in the inline view
+}
+
+// buildSuggestions collects the pending suggestions of a document fetched with suggestions inline,
+// in order of their first appearance in the body.
+func buildSuggestions(doc *docs.Document) []*Suggestion {
+	if doc.Body == nil {
+		return nil
+	}
+
+	collector := &suggestionCollector{byID: make(map[string]*Suggestion)}
+	collector.collectElements(doc.Body.Content)
+	return collector.suggestions
+}
+
+// suggestionCollector groups the text runs of each suggestion by suggestion ID
+type suggestionCollector struct {
+	suggestions []*Suggestion
+	byID        map[string]*Suggestion
+}
+
+// suggestion returns the suggestion with the given ID, extending its range to [start, end)
+func (c *suggestionCollector) suggestion(id string, start, end int64) *Suggestion {
+	s, ok := c.byID[id]
+	if !ok {
+		s = &Suggestion{ID: id, StartIndex: start, EndIndex: end}
+		c.byID[id] = s
+		c.suggestions = append(c.suggestions, s)
+	}
+	s.StartIndex = min(s.StartIndex, start)
+	s.EndIndex = max(s.EndIndex, end)
+	return s
+}
+
+// collectElements collects suggestions from structural elements, descending into tables
+func (c *suggestionCollector) collectElements(elements []*docs.StructuralElement) {
+	for _, element := range elements {
+		if element.Paragraph != nil {
+			c.collectParagraph(element.Paragraph, element.StartIndex, element.EndIndex)
+		}
+
+		if element.Table != nil {
+			for _, row := range element.Table.TableRows {
+				for _, cell := range row.TableCells {
+					c.collectElements(cell.Content)
+				}
+			}
+		}
+	}
+}
+
+// collectParagraph collects suggestions from the text runs and style changes of a paragraph
+func (c *suggestionCollector) collectParagraph(paragraph *docs.Paragraph, start, end int64) {
+	for _, elem := range paragraph.Elements {
+		run := elem.TextRun
+		if run == nil {
+			continue
+		}
+
+		for _, id := range run.SuggestedInsertionIds {
+			c.suggestion(id, elem.StartIndex, elem.EndIndex).InsertedText += run.Content
+		}
+		for _, id := range run.SuggestedDeletionIds {
+			c.suggestion(id, elem.StartIndex, elem.EndIndex).DeletedText += run.Content
+		}
+		for _, id := range sortedKeys(run.SuggestedTextStyleChanges) {
+			c.suggestion(id, elem.StartIndex, elem.EndIndex).StyleChanged = true
+		}
+	}
+
+	for _, id := range sortedKeys(paragraph.SuggestedParagraphStyleChanges) {
+		c.suggestion(id, start, end).StyleChanged = true
+	}
+	for _, id := range sortedKeys(paragraph.SuggestedBulletChanges) {
+		c.suggestion(id, start, end).StyleChanged = true
+	}
+}
+
+// sortedKeys returns the keys of a map in ascending order
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// RenderSuggestions renders pending suggestions one per line as
+// "<id>\t[<start>-<end>)\t+<inserted>\t-<deleted>"
+func RenderSuggestions(suggestions []*Suggestion) string {
+	var builder strings.Builder
+	for _, s := range suggestions {
+		fmt.Fprintf(&builder, "%s\t[%d-%d)", s.ID, s.StartIndex, s.EndIndex)
+		if s.InsertedText != "" {
+			fmt.Fprintf(&builder, "\t+%q", s.InsertedText)
+		}
+		if s.DeletedText != "" {
+			fmt.Fprintf(&builder, "\t-%q", s.DeletedText)
+		}
+		if s.StyleChanged {
+			builder.WriteString("\t(formatting)")
+		}
+		builder.WriteString("\n")
+	}
+	return builder.String()
+}
@@ -0,0 +1,102 @@
+package review
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/api/docs/v1"
+)
+
+func TestBuildSuggestions(t *testing.T) {
+	styled := paragraphElement(20, "NORMAL_TEXT", "見出し\n")
+	styled.Paragraph.SuggestedParagraphStyleChanges = map[string]docs.SuggestedParagraphStyle{"suggest.style": {}}
+
+	doc := &docs.Document{
+		Body: &docs.Body{
+			Content: []*docs.StructuralElement{
+				{
+					StartIndex: 1,
+					EndIndex:   20,
+					Paragraph: &docs.Paragraph{
+						Elements: []*docs.ParagraphElement{
+							{StartIndex: 1, EndIndex: 8, TextRun: &docs.TextRun{Content: "テストデザイン"}},
+							{StartIndex: 8, EndIndex: 11, TextRun: &docs.TextRun{Content: "ドッグ", SuggestedDeletionIds: []string{"suggest.typo"}}},
+							{StartIndex: 11, EndIndex: 17, TextRun: &docs.TextRun{Content: "ドキュメント", SuggestedInsertionIds: []string{"suggest.typo"}}},
+							{StartIndex: 17, EndIndex: 20, TextRun: &docs.TextRun{Content: "です\n"}},
+						},
+					},
+				},
+				styled,
+				{
+					StartIndex: 24,
+					EndIndex:   30,
+					Table: &docs.Table{
+						TableRows: []*docs.TableRow{
+							{TableCells: []*docs.TableCell{
+								{Content: []*docs.StructuralElement{
+									{
+										StartIndex: 25,
+										EndIndex:   29,
+										Paragraph: &docs.Paragraph{
+											Elements: []*docs.ParagraphElement{
+												{StartIndex: 25, EndIndex: 29, TextRun: &docs.TextRun{Content: "追加\n", SuggestedInsertionIds: []string{"suggest.cell"}}},
+											},
+										},
+									},
+								}},
+							}},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	want := []*Suggestion{
+		{ID: "suggest.typo", InsertedText: "ドキュメント", DeletedText: "ドッグ", StartIndex: 8, EndIndex: 17},
+		{ID: "suggest.style", StyleChanged: true, StartIndex: 20, EndIndex: 24},
+		{ID: "suggest.cell", InsertedText: "追加\n", StartIndex: 25, EndIndex: 29},
+	}
+	if diff := cmp.Diff(want, buildSuggestions(doc)); diff != "" {
+		t.Errorf("buildSuggestions() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestSuggestionsView_Mode(t *testing.T) {
+	tests := []struct {
+		view    SuggestionsView
+		want    string
+		wantErr bool
+	}{
+		{view: SuggestionsViewDefault, want: "DEFAULT_FOR_CURRENT_ACCESS"},
+		{view: SuggestionsViewInline, want: "SUGGESTIONS_INLINE"},
+		{view: SuggestionsViewAccepted, want: "PREVIEW_SUGGESTIONS_ACCEPTED"},
+		{view: SuggestionsViewRejected, want: "PREVIEW_WITHOUT_SUGGESTIONS"},
+		{view: "pending", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.view), func(t *testing.T) {
+			got, err := tt.view.mode()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("mode() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("mode() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRenderSuggestions(t *testing.T) {
+	suggestions := []*Suggestion{
+		{ID: "suggest.typo", InsertedText: "ドキュメント", DeletedText: "ドッグ", StartIndex: 8, EndIndex: 17},
+		{ID: "suggest.style", StyleChanged: true, StartIndex: 20, EndIndex: 24},
+	}
+
+	want := "suggest.typo\t[8-17)\t+\"ドキュメント\"\t-\"ドッグ\"\n" +
+		"suggest.style\t[20-24)\t(formatting)\n"
+	if diff := cmp.Diff(want, RenderSuggestions(suggestions)); diff != "" {
+		t.Errorf("RenderSuggestions() mismatch (-want +got):\n%s", diff)
+	}
+}
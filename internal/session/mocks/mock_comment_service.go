@@ -0,0 +1,102 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/takeuchi-shogo/google-doc-review/internal/session (interfaces: CommentService)
+//
+// Generated by this command:
+//
+//	mockgen -destination=mocks/mock_comment_service.go -package=mocks github.com/takeuchi-shogo/google-doc-review/internal/session CommentService
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	comment "github.com/takeuchi-shogo/google-doc-review/internal/comment"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockCommentService is a mock of CommentService interface.
+type MockCommentService struct {
+	ctrl     *gomock.Controller
+	recorder *MockCommentServiceMockRecorder
+	isgomock struct{}
+}
+
+// MockCommentServiceMockRecorder is the mock recorder for MockCommentService.
+type MockCommentServiceMockRecorder struct {
+	mock *MockCommentService
+}
+
+// NewMockCommentService creates a new mock instance.
+func NewMockCommentService(ctrl *gomock.Controller) *MockCommentService {
+	mock := &MockCommentService{ctrl: ctrl}
+	mock.recorder = &MockCommentServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockCommentService) EXPECT() *MockCommentServiceMockRecorder {
+	return m.recorder
+}
+
+// CreateCommentFromIssue mocks base method.
+func (m *MockCommentService) CreateCommentFromIssue(ctx context.Context, fileID string, issue comment.Issue, idempotencyKey string) (*comment.CommentResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateCommentFromIssue", ctx, fileID, issue, idempotencyKey)
+	ret0, _ := ret[0].(*comment.CommentResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateCommentFromIssue indicates an expected call of CreateCommentFromIssue.
+func (mr *MockCommentServiceMockRecorder) CreateCommentFromIssue(ctx, fileID, issue, idempotencyKey any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateCommentFromIssue", reflect.TypeOf((*MockCommentService)(nil).CreateCommentFromIssue), ctx, fileID, issue, idempotencyKey)
+}
+
+// DocumentRevision mocks base method.
+func (m *MockCommentService) DocumentRevision(ctx context.Context, fileID string) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DocumentRevision", ctx, fileID)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DocumentRevision indicates an expected call of DocumentRevision.
+func (mr *MockCommentServiceMockRecorder) DocumentRevision(ctx, fileID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DocumentRevision", reflect.TypeOf((*MockCommentService)(nil).DocumentRevision), ctx, fileID)
+}
+
+// ListComments mocks base method.
+func (m *MockCommentService) ListComments(ctx context.Context, fileID string, opts *comment.ListCommentsOptions) ([]*comment.Comment, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListComments", ctx, fileID, opts)
+	ret0, _ := ret[0].([]*comment.Comment)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListComments indicates an expected call of ListComments.
+func (mr *MockCommentServiceMockRecorder) ListComments(ctx, fileID, opts any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListComments", reflect.TypeOf((*MockCommentService)(nil).ListComments), ctx, fileID, opts)
+}
+
+// ResolveComment mocks base method.
+func (m *MockCommentService) ResolveComment(ctx context.Context, fileID, commentID, content string) (*comment.Reply, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ResolveComment", ctx, fileID, commentID, content)
+	ret0, _ := ret[0].(*comment.Reply)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ResolveComment indicates an expected call of ResolveComment.
+func (mr *MockCommentServiceMockRecorder) ResolveComment(ctx, fileID, commentID, content any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResolveComment", reflect.TypeOf((*MockCommentService)(nil).ResolveComment), ctx, fileID, commentID, content)
+}
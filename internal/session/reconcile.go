@@ -0,0 +1,218 @@
+package session
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/takeuchi-shogo/google-doc-review/internal/comment"
+)
+
+// Fingerprint returns a stable identifier of an issue across reviews.
+// Issues are identified by type and the text they point at, normalized like the comment matcher
+// normalizes quotes, so rewording the description or the context hints, or quoting with different
+// punctuation or character widths, does not create a new issue. Issues without quoted text fall
+// back to their description.
+func Fingerprint(issue comment.Issue) string {
+	parts := []string{string(issue.Type)}
+	if issue.TextContent != "" {
+		parts = append(parts, comment.NormalizeQuote(issue.TextContent))
+	} else {
+		parts = append(parts, fmt.Sprint(issue.LineNumber), comment.NormalizeQuote(issue.Description))
+	}
+
+	sum := sha256.Sum256([]byte(strings.Join(parts, "\x00")))
+	return hex.EncodeToString(sum[:16])
+}
+
+// CommentService is the subset of comment.CommentManager used to reconcile a review
+//
+//go:generate mockgen -destination=mocks/mock_comment_service.go -package=mocks github.com/takeuchi-shogo/google-doc-review/internal/session CommentService
+type CommentService interface {
+	CreateCommentFromIssue(ctx context.Context, fileID string, issue comment.Issue, idempotencyKey string) (*comment.CommentResponse, error)
+	ListComments(ctx context.Context, fileID string, opts *comment.ListCommentsOptions) ([]*comment.Comment, error)
+	ResolveComment(ctx context.Context, fileID, commentID, content string) (*comment.Reply, error)
+	DocumentRevision(ctx context.Context, fileID string) (string, error)
+}
+
+// Action is what reconciling did with an issue
+type Action string
+
+const (
+	ActionPosted   Action = "posted"   // A new issue was posted as a comment
+	ActionSkipped  Action = "skipped"  // The issue is already open
+	ActionResolved Action = "resolved" // The issue was not reported again and its comment was resolved
+	ActionFailed   Action = "failed"
+)
+
+// Outcome is the result of reconciling a single issue
+type Outcome struct {
+	Action      Action                   `json:"action"`
+	Fingerprint string                   `json:"fingerprint"`
+	CommentID   string                   `json:"comment_id,omitempty"`
+	Issue       comment.Issue            `json:"issue"`
+	Comment     *comment.CommentResponse `json:"-"` // Posted comment, set for ActionPosted
+	Err         error                    `json:"-"` // Why the issue failed, set for ActionFailed
+}
+
+// Result is the result of reconciling a review with its session
+type Result struct {
+	DocumentID string     `json:"document_id"`
+	Revision   string     `json:"revision"`
+	Outcomes   []*Outcome `json:"outcomes"`
+}
+
+// Count returns the number of outcomes with the given action
+func (r *Result) Count(action Action) int {
+	count := 0
+	for _, outcome := range r.Outcomes {
+		if outcome.Action == action {
+			count++
+		}
+	}
+	return count
+}
+
+// resolveMessage is the reply posted when an issue is no longer reported
+const resolveMessage = "Resolved automatically: this issue was not found in the review of revision %s."
+
+// Reconciler posts the issues of a review, taking earlier reviews of the same document into account
+type Reconciler struct {
+	comments CommentService
+	store    *Store
+	now      func() time.Time
+}
+
+// NewReconciler creates a Reconciler
+func NewReconciler(comments CommentService, store *Store) *Reconciler {
+	return &Reconciler{comments: comments, store: store, now: time.Now}
+}
+
+// Reconcile compares the issues of a new review with the document's session.
+// Issues that are still open are skipped, new ones are posted and open issues that are no longer
+// reported are resolved with a reply, so issues must be the complete result of the review.
+// An open issue whose comment was deleted counts as not posted.
+// The session is saved even if some comments fail; if saving fails, the result is returned along
+// with the error, as its comments were already posted. Reconciliations of the same document are serialized.
+func (r *Reconciler) Reconcile(ctx context.Context, documentID string, issues []comment.Issue) (*Result, error) {
+	unlock := r.store.Lock(documentID)
+	defer unlock()
+
+	session, err := r.store.Load(documentID)
+	if err != nil {
+		return nil, err
+	}
+
+	revision, err := r.comments.DocumentRevision(ctx, documentID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Comments deleted since they were recorded, e.g. by purge_bot_comments, count as not posted
+	comments, err := r.comments.ListComments(ctx, documentID, nil)
+	if err != nil {
+		return nil, err
+	}
+	live := make(map[string]bool, len(comments))
+	untracked := make(map[string]*comment.Comment)
+	for _, c := range comments {
+		if c.Deleted {
+			continue
+		}
+		live[c.ID] = true
+		if c.IdempotencyKey != "" && !c.Resolved {
+			untracked[c.IdempotencyKey] = c
+		}
+	}
+
+	result := &Result{DocumentID: documentID, Revision: revision}
+	reported := make(map[string]bool, len(issues))
+
+	for _, issue := range issues {
+		fingerprint := Fingerprint(issue)
+		if reported[fingerprint] {
+			continue
+		}
+		reported[fingerprint] = true
+
+		record := session.find(fingerprint)
+		if record != nil && record.Status == StatusOpen && live[record.CommentID] {
+			record.Revision = revision
+			result.Outcomes = append(result.Outcomes, &Outcome{Action: ActionSkipped, Fingerprint: fingerprint, CommentID: record.CommentID, Issue: issue})
+			continue
+		}
+
+		// A comment posted by an earlier run whose session was not saved is adopted instead of posted again.
+		// Otherwise the fingerprint is the idempotency key, so a post that timed out after Drive
+		// accepted it is not repeated.
+		outcome := &Outcome{Action: ActionSkipped, Fingerprint: fingerprint, Issue: issue}
+		if posted, ok := untracked[fingerprint]; ok {
+			outcome.CommentID = posted.ID
+		} else {
+			resp, err := r.comments.CreateCommentFromIssue(ctx, documentID, issue, fingerprint)
+			if err != nil {
+				outcome.Action = ActionFailed
+				outcome.Err = err
+				result.Outcomes = append(result.Outcomes, outcome)
+				continue
+			}
+			outcome.Action = ActionPosted
+			outcome.CommentID = resp.CommentID
+			outcome.Comment = resp
+		}
+
+		// A resolved issue, or one whose comment was deleted, that is reported again is posted as a new comment
+		live[outcome.CommentID] = true
+		if record == nil {
+			record = &Record{Fingerprint: fingerprint}
+			session.Records = append(session.Records, record)
+		}
+		record.CommentID = outcome.CommentID
+		record.Revision = revision
+		record.Status = StatusOpen
+		record.Issue = issue
+		record.PostedAt = r.now()
+		record.ResolvedAt = nil
+
+		result.Outcomes = append(result.Outcomes, outcome)
+	}
+
+	for _, record := range session.Records {
+		if record.Status != StatusOpen || reported[record.Fingerprint] || !live[record.CommentID] {
+			continue
+		}
+
+		_, err := r.comments.ResolveComment(ctx, documentID, record.CommentID, fmt.Sprintf(resolveMessage, revision))
+		if err != nil {
+			result.Outcomes = append(result.Outcomes, &Outcome{Action: ActionFailed, Fingerprint: record.Fingerprint, CommentID: record.CommentID, Issue: record.Issue, Err: err})
+			continue
+		}
+
+		resolvedAt := r.now()
+		record.Status = StatusResolved
+		record.Revision = revision
+		record.ResolvedAt = &resolvedAt
+
+		result.Outcomes = append(result.Outcomes, &Outcome{Action: ActionResolved, Fingerprint: record.Fingerprint, CommentID: record.CommentID, Issue: record.Issue})
+	}
+
+	// Forget open issues whose comment is gone, so they are posted again when reported
+	records := make([]*Record, 0, len(session.Records))
+	for _, record := range session.Records {
+		if record.Status == StatusOpen && !live[record.CommentID] {
+			continue
+		}
+		records = append(records, record)
+	}
+	session.Records = records
+
+	session.UpdatedAt = r.now()
+	if err := r.store.Save(session); err != nil {
+		return result, err
+	}
+
+	return result, nil
+}
@@ -0,0 +1,331 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/takeuchi-shogo/google-doc-review/internal/comment"
+	"github.com/takeuchi-shogo/google-doc-review/internal/session/mocks"
+	"go.uber.org/mock/gomock"
+)
+
+func TestFingerprint(t *testing.T) {
+	base := comment.Issue{
+		Type:        comment.IssueTypeGrammar,
+		Severity:    comment.SeverityCritical,
+		TextContent: "テストデザインドッグ",
+		Description: "誤字があります",
+	}
+
+	tests := []struct {
+		name  string
+		issue func(comment.Issue) comment.Issue
+		same  bool
+	}{
+		{
+			name:  "reworded description",
+			issue: func(i comment.Issue) comment.Issue { i.Description = "タイトルに誤字"; return i },
+			same:  true,
+		},
+		{
+			name:  "different severity",
+			issue: func(i comment.Issue) comment.Issue { i.Severity = comment.SeverityInfo; return i },
+			same:  true,
+		},
+		{
+			name:  "whitespace in quote",
+			issue: func(i comment.Issue) comment.Issue { i.TextContent = "  テストデザインドッグ "; return i },
+			same:  true,
+		},
+		{
+			name:  "trailing punctuation and width in quote",
+			issue: func(i comment.Issue) comment.Issue { i.TextContent = "ﾃｽﾄデザインドッグ。"; return i },
+			same:  true,
+		},
+		{
+			name: "rewritten context hints",
+			issue: func(i comment.Issue) comment.Issue {
+				i.Occurrence = 1
+				i.ContextBefore = "タイトル:"
+				i.ContextAfter = "の説明"
+				return i
+			},
+			same: true,
+		},
+		{
+			name:  "different type",
+			issue: func(i comment.Issue) comment.Issue { i.Type = comment.IssueTypeClarity; return i },
+		},
+		{
+			name:  "different quote",
+			issue: func(i comment.Issue) comment.Issue { i.TextContent = "テストテスト"; return i },
+		},
+		{
+			name: "no quote uses description",
+			issue: func(i comment.Issue) comment.Issue {
+				i.TextContent = ""
+				return i
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Fingerprint(tt.issue(base)) == Fingerprint(base)
+			if got != tt.same {
+				t.Errorf("same fingerprint = %v, want %v", got, tt.same)
+			}
+		})
+	}
+}
+
+func TestReconciler_Reconcile(t *testing.T) {
+	typo := comment.Issue{Type: comment.IssueTypeGrammar, Severity: comment.SeverityCritical, TextContent: "ドッグ", Description: "誤字"}
+	missing := comment.Issue{Type: comment.IssueTypeMissing, Severity: comment.SeverityWarning, Description: "概要がありません"}
+	vague := comment.Issue{Type: comment.IssueTypeClarity, Severity: comment.SeverityInfo, TextContent: "テストテスト", Description: "内容不足"}
+
+	now := time.Date(2025, 10, 26, 9, 0, 0, 0, time.UTC)
+	ctx := context.Background()
+
+	ctrl := gomock.NewController(t)
+	comments := mocks.NewMockCommentService(ctrl)
+
+	store := NewStore(t.TempDir())
+	if err := store.Save(&Session{
+		DocumentID: "doc-1",
+		Records: []*Record{
+			{Fingerprint: Fingerprint(typo), CommentID: "comment-typo", Revision: "rev-1", Status: StatusOpen, Issue: typo},
+			{Fingerprint: Fingerprint(missing), CommentID: "comment-missing", Revision: "rev-1", Status: StatusOpen, Issue: missing},
+		},
+	}); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	// The typo is still reported, the missing section was added and a new issue was found
+	comments.EXPECT().DocumentRevision(ctx, "doc-1").Return("rev-2", nil)
+	comments.EXPECT().ListComments(ctx, "doc-1", nil).Return([]*comment.Comment{{ID: "comment-typo"}, {ID: "comment-missing"}}, nil)
+	comments.EXPECT().CreateCommentFromIssue(ctx, "doc-1", vague, Fingerprint(vague)).Return(&comment.CommentResponse{CommentID: "comment-vague"}, nil)
+	comments.EXPECT().
+		ResolveComment(ctx, "doc-1", "comment-missing", "Resolved automatically: this issue was not found in the review of revision rev-2.").
+		Return(&comment.Reply{ID: "reply-1"}, nil)
+
+	reconciler := NewReconciler(comments, store)
+	reconciler.now = func() time.Time { return now }
+
+	result, err := reconciler.Reconcile(ctx, "doc-1", []comment.Issue{typo, vague, typo})
+	if err != nil {
+		t.Fatalf("Reconcile() error = %v", err)
+	}
+
+	type outcomeSummary struct {
+		Action    Action
+		CommentID string
+	}
+	got := make([]outcomeSummary, 0, len(result.Outcomes))
+	for _, outcome := range result.Outcomes {
+		got = append(got, outcomeSummary{outcome.Action, outcome.CommentID})
+	}
+	want := []outcomeSummary{
+		{ActionSkipped, "comment-typo"},
+		{ActionPosted, "comment-vague"},
+		{ActionResolved, "comment-missing"},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("outcomes mismatch (-want +got):\n%s", diff)
+	}
+
+	session, err := store.Load("doc-1")
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	wantRecords := []*Record{
+		{Fingerprint: Fingerprint(typo), CommentID: "comment-typo", Revision: "rev-2", Status: StatusOpen, Issue: typo},
+		{Fingerprint: Fingerprint(missing), CommentID: "comment-missing", Revision: "rev-2", Status: StatusResolved, Issue: missing, ResolvedAt: &now},
+		{Fingerprint: Fingerprint(vague), CommentID: "comment-vague", Revision: "rev-2", Status: StatusOpen, Issue: vague, PostedAt: now},
+	}
+	if diff := cmp.Diff(wantRecords, session.Records); diff != "" {
+		t.Errorf("session records mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestReconciler_DeletedComments(t *testing.T) {
+	typo := comment.Issue{Type: comment.IssueTypeGrammar, Severity: comment.SeverityCritical, TextContent: "ドッグ", Description: "誤字"}
+	missing := comment.Issue{Type: comment.IssueTypeMissing, Severity: comment.SeverityWarning, Description: "概要がありません"}
+	ctx := context.Background()
+
+	ctrl := gomock.NewController(t)
+	comments := mocks.NewMockCommentService(ctrl)
+
+	store := NewStore(t.TempDir())
+	if err := store.Save(&Session{
+		DocumentID: "doc-1",
+		Records: []*Record{
+			{Fingerprint: Fingerprint(typo), CommentID: "comment-typo", Revision: "rev-1", Status: StatusOpen, Issue: typo},
+			{Fingerprint: Fingerprint(missing), CommentID: "comment-missing", Revision: "rev-1", Status: StatusOpen, Issue: missing},
+		},
+	}); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	// Both comments were purged: the typo is posted again, the missing issue is forgotten without a reply
+	comments.EXPECT().DocumentRevision(ctx, "doc-1").Return("rev-2", nil)
+	comments.EXPECT().ListComments(ctx, "doc-1", nil).Return([]*comment.Comment{{ID: "comment-typo", Deleted: true}}, nil)
+	comments.EXPECT().CreateCommentFromIssue(ctx, "doc-1", typo, Fingerprint(typo)).Return(&comment.CommentResponse{CommentID: "comment-typo-2"}, nil)
+
+	result, err := NewReconciler(comments, store).Reconcile(ctx, "doc-1", []comment.Issue{typo})
+	if err != nil {
+		t.Fatalf("Reconcile() error = %v", err)
+	}
+	if result.Count(ActionPosted) != 1 || result.Count(ActionResolved) != 0 {
+		t.Errorf("outcomes = %+v, want the typo posted again and nothing resolved", result.Outcomes)
+	}
+
+	session, err := store.Load("doc-1")
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if len(session.Records) != 1 || session.Records[0].CommentID != "comment-typo-2" {
+		t.Errorf("session records = %+v, want only the reposted typo", session.Records)
+	}
+}
+
+func TestReconciler_AdoptsUntrackedComment(t *testing.T) {
+	issue := comment.Issue{Type: comment.IssueTypeGrammar, Severity: comment.SeverityCritical, TextContent: "ドッグ", Description: "誤字"}
+	ctx := context.Background()
+
+	ctrl := gomock.NewController(t)
+	comments := mocks.NewMockCommentService(ctrl)
+
+	// An earlier run posted the comment with the fingerprint as key but failed to save the session
+	posted := &comment.Comment{ID: "comment-1", IdempotencyKey: Fingerprint(issue)}
+	comments.EXPECT().DocumentRevision(ctx, "doc-1").Return("rev-1", nil)
+	comments.EXPECT().ListComments(ctx, "doc-1", nil).Return([]*comment.Comment{posted}, nil)
+
+	store := NewStore(t.TempDir())
+	result, err := NewReconciler(comments, store).Reconcile(ctx, "doc-1", []comment.Issue{issue})
+	if err != nil {
+		t.Fatalf("Reconcile() error = %v", err)
+	}
+	if result.Count(ActionSkipped) != 1 || result.Outcomes[0].CommentID != "comment-1" {
+		t.Errorf("outcomes = %+v, want the posted comment adopted", result.Outcomes)
+	}
+
+	session, err := store.Load("doc-1")
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if len(session.Records) != 1 || session.Records[0].CommentID != "comment-1" {
+		t.Errorf("session records = %+v, want the adopted comment", session.Records)
+	}
+}
+
+func TestReconciler_ReportsFailures(t *testing.T) {
+	issue := comment.Issue{Type: comment.IssueTypeGrammar, Severity: comment.SeverityCritical, TextContent: "ドッグ", Description: "誤字"}
+	ctx := context.Background()
+
+	ctrl := gomock.NewController(t)
+	comments := mocks.NewMockCommentService(ctrl)
+	comments.EXPECT().DocumentRevision(ctx, "doc-1").Return("rev-1", nil)
+	comments.EXPECT().ListComments(ctx, "doc-1", nil).Return(nil, nil)
+	comments.EXPECT().CreateCommentFromIssue(ctx, "doc-1", issue, Fingerprint(issue)).Return(nil, errors.New("quota exceeded"))
+
+	store := NewStore(t.TempDir())
+	result, err := NewReconciler(comments, store).Reconcile(ctx, "doc-1", []comment.Issue{issue})
+	if err != nil {
+		t.Fatalf("Reconcile() error = %v", err)
+	}
+
+	if result.Count(ActionFailed) != 1 || result.Outcomes[0].Err == nil {
+		t.Errorf("outcomes = %+v, want one failure with an error", result.Outcomes)
+	}
+
+	// A failed issue is not recorded, so the next review posts it again
+	session, err := store.Load("doc-1")
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if len(session.Records) != 0 {
+		t.Errorf("session has %d records, want none", len(session.Records))
+	}
+}
+
+func TestReconciler_Concurrent(t *testing.T) {
+	issue := comment.Issue{Type: comment.IssueTypeGrammar, Severity: comment.SeverityCritical, TextContent: "ドッグ", Description: "誤字"}
+	ctx := context.Background()
+
+	ctrl := gomock.NewController(t)
+	comments := mocks.NewMockCommentService(ctrl)
+	var (
+		mu     sync.Mutex
+		posted []*comment.Comment
+	)
+	comments.EXPECT().DocumentRevision(ctx, "doc-1").Return("rev-1", nil).Times(5)
+	comments.EXPECT().ListComments(ctx, "doc-1", nil).DoAndReturn(func(context.Context, string, *comment.ListCommentsOptions) ([]*comment.Comment, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		return posted, nil
+	}).Times(5)
+	// Every run after the first sees the posted record and skips the issue
+	comments.EXPECT().CreateCommentFromIssue(ctx, "doc-1", issue, Fingerprint(issue)).DoAndReturn(func(context.Context, string, comment.Issue, string) (*comment.CommentResponse, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		posted = append(posted, &comment.Comment{ID: "comment-1"})
+		return &comment.CommentResponse{CommentID: "comment-1"}, nil
+	}).Times(1)
+
+	store := NewStore(t.TempDir())
+	reconciler := NewReconciler(comments, store)
+
+	var wg sync.WaitGroup
+	for range 5 {
+		wg.Go(func() {
+			if _, err := reconciler.Reconcile(ctx, "doc-1", []comment.Issue{issue}); err != nil {
+				t.Errorf("Reconcile() error = %v", err)
+			}
+		})
+	}
+	wg.Wait()
+
+	session, err := store.Load("doc-1")
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if len(session.Records) != 1 {
+		t.Errorf("session has %d records, want 1", len(session.Records))
+	}
+	if len(store.locks) != 0 {
+		t.Errorf("store holds %d document locks after the runs, want 0", len(store.locks))
+	}
+}
+
+func TestReconciler_SaveFailure(t *testing.T) {
+	issue := comment.Issue{Type: comment.IssueTypeGrammar, Severity: comment.SeverityCritical, TextContent: "ドッグ", Description: "誤字"}
+	ctx := context.Background()
+	dir := filepath.Join(t.TempDir(), "sessions")
+
+	ctrl := gomock.NewController(t)
+	comments := mocks.NewMockCommentService(ctrl)
+	comments.EXPECT().DocumentRevision(ctx, "doc-1").Return("rev-1", nil)
+	comments.EXPECT().ListComments(ctx, "doc-1", nil).Return(nil, nil)
+	comments.EXPECT().CreateCommentFromIssue(ctx, "doc-1", issue, Fingerprint(issue)).DoAndReturn(func(context.Context, string, comment.Issue, string) (*comment.CommentResponse, error) {
+		// A regular file in place of the session directory makes saving fail
+		if err := os.WriteFile(dir, nil, 0600); err != nil {
+			t.Fatalf("failed to create file: %v", err)
+		}
+		return &comment.CommentResponse{CommentID: "comment-1"}, nil
+	})
+
+	result, err := NewReconciler(comments, NewStore(dir)).Reconcile(ctx, "doc-1", []comment.Issue{issue})
+	if err == nil {
+		t.Fatal("Reconcile() error = nil, want the save error")
+	}
+	if result == nil || result.Count(ActionPosted) != 1 {
+		t.Errorf("Reconcile() result = %+v, want the posted comment despite the save error", result)
+	}
+}
@@ -0,0 +1,175 @@
+package session
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/takeuchi-shogo/google-doc-review/internal/comment"
+)
+
+// Status is the state of a posted issue
+type Status string
+
+const (
+	StatusOpen     Status = "open"     // The issue was reported in the latest review
+	StatusResolved Status = "resolved" // The issue was not reported again and its comment was resolved
+)
+
+// Record is an issue posted as a comment during a review session
+type Record struct {
+	Fingerprint string        `json:"fingerprint"`
+	CommentID   string        `json:"comment_id"`
+	Revision    string        `json:"revision"` // Document revision the issue was last reported against
+	Status      Status        `json:"status"`
+	Issue       comment.Issue `json:"issue"`
+	PostedAt    time.Time     `json:"posted_at"`
+	ResolvedAt  *time.Time    `json:"resolved_at,omitempty"`
+}
+
+// Session is the review history of a single document
+type Session struct {
+	DocumentID string    `json:"document_id"`
+	Records    []*Record `json:"records"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// find returns the record with the given fingerprint
+func (s *Session) find(fingerprint string) *Record {
+	for _, record := range s.Records {
+		if record.Fingerprint == fingerprint {
+			return record
+		}
+	}
+	return nil
+}
+
+// Store keeps review sessions as one JSON file per document
+type Store struct {
+	dir string
+
+	mu    sync.Mutex
+	locks map[string]*documentLock
+}
+
+// documentLock serializes the updates of one document's session
+type documentLock struct {
+	mu   sync.Mutex
+	refs int // Holders and waiters, the lock is dropped when it reaches 0
+}
+
+// NewStore creates a Store that keeps its files in dir
+func NewStore(dir string) *Store {
+	return &Store{dir: dir, locks: make(map[string]*documentLock)}
+}
+
+// Lock serializes Load and Save of a document's session between callers of the same Store, so
+// concurrent reviews of a document do not overwrite each other's records. It returns the function
+// that releases the lock.
+func (s *Store) Lock(documentID string) (unlock func()) {
+	s.mu.Lock()
+	lock, ok := s.locks[documentID]
+	if !ok {
+		lock = &documentLock{}
+		s.locks[documentID] = lock
+	}
+	lock.refs++
+	s.mu.Unlock()
+
+	lock.mu.Lock()
+	return func() {
+		lock.mu.Unlock()
+
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		lock.refs--
+		if lock.refs == 0 {
+			delete(s.locks, documentID)
+		}
+	}
+}
+
+// DefaultDir returns the directory sessions are stored in by default
+func DefaultDir() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get home directory: %w", err)
+	}
+	return filepath.Join(home, ".google-doc-review", "sessions"), nil
+}
+
+// documentIDPattern matches the characters of a Google Docs document ID
+var documentIDPattern = regexp.MustCompile(`^[a-zA-Z0-9-_]+$`)
+
+// path returns the file of a document's session
+func (s *Store) path(documentID string) (string, error) {
+	if !documentIDPattern.MatchString(documentID) {
+		return "", fmt.Errorf("invalid document ID: %q", documentID)
+	}
+	return filepath.Join(s.dir, documentID+".json"), nil
+}
+
+// Load returns the session of a document, or an empty session if there is none yet
+func (s *Store) Load(documentID string) (*Session, error) {
+	path, err := s.path(documentID)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return &Session{DocumentID: documentID}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read session file: %w", err)
+	}
+
+	var session Session
+	if err := json.Unmarshal(data, &session); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal session: %w", err)
+	}
+
+	return &session, nil
+}
+
+// Save writes the session of a document, replacing the previous file atomically
+func (s *Store) Save(session *Session) error {
+	path, err := s.path(session.DocumentID)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(s.dir, 0700); err != nil {
+		return fmt.Errorf("failed to create session directory: %w", err)
+	}
+
+	data, err := json.MarshalIndent(session, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal session: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(s.dir, session.DocumentID+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to create session file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write session file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write session file: %w", err)
+	}
+
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to replace session file: %w", err)
+	}
+
+	return nil
+}
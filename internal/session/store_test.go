@@ -0,0 +1,77 @@
+package session
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/takeuchi-shogo/google-doc-review/internal/comment"
+)
+
+func TestStore_LoadMissing(t *testing.T) {
+	store := NewStore(t.TempDir())
+
+	got, err := store.Load("doc-1")
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	want := &Session{DocumentID: "doc-1"}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Load() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestStore_SaveAndLoad(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "sessions")
+	store := NewStore(dir)
+
+	postedAt := time.Date(2025, 10, 25, 12, 0, 0, 0, time.UTC)
+	session := &Session{
+		DocumentID: "doc-1",
+		Records: []*Record{
+			{
+				Fingerprint: "fp-1",
+				CommentID:   "comment-1",
+				Revision:    "rev-1",
+				Status:      StatusOpen,
+				Issue:       comment.Issue{Type: comment.IssueTypeGrammar, Severity: comment.SeverityCritical, TextContent: "ドッグ", Description: "誤字"},
+				PostedAt:    postedAt,
+			},
+		},
+		UpdatedAt: postedAt,
+	}
+
+	if err := store.Save(session); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	info, err := os.Stat(filepath.Join(dir, "doc-1.json"))
+	if err != nil {
+		t.Fatalf("session file not written: %v", err)
+	}
+	if perm := info.Mode().Perm(); perm != 0600 {
+		t.Errorf("session file permissions = %o, want 600", perm)
+	}
+
+	got, err := store.Load("doc-1")
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if diff := cmp.Diff(session, got); diff != "" {
+		t.Errorf("Load() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestStore_InvalidDocumentID(t *testing.T) {
+	store := NewStore(t.TempDir())
+
+	if _, err := store.Load("../token"); err == nil {
+		t.Error("Load() error = nil, want error for a path-like document ID")
+	}
+	if err := store.Save(&Session{DocumentID: "a/b"}); err == nil {
+		t.Error("Save() error = nil, want error for a path-like document ID")
+	}
+}
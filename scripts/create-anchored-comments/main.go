@@ -6,8 +6,8 @@ import (
 	"log"
 
 	"github.com/takeuchi-shogo/google-doc-review/config"
-	"github.com/takeuchi-shogo/google-doc-review/internal/authmanager"
 	"github.com/takeuchi-shogo/google-doc-review/internal/comment"
+	"github.com/takeuchi-shogo/google-doc-review/internal/googleclient"
 )
 
 func main() {
@@ -19,19 +19,14 @@ func main() {
 		log.Fatalf("failed to load config: %v", err)
 	}
 
-	// 認証してHTTPクライアントを取得
-	authMgr := authmanager.NewWithConfig(
-		cfg.Google.ClientID,
-		cfg.Google.ClientSecret,
-		&authmanager.BrowserAuthenticator{},
-	)
-	client, err := authMgr.GetOrAuthenticateClient(ctx)
+	// 認証し、Google APIのクライアント一式を作成
+	clients, err := googleclient.New(ctx, cfg)
 	if err != nil {
-		log.Fatalf("failed to get authenticated client: %v", err)
+		log.Fatalf("failed to create Google clients: %v", err)
 	}
 
 	// CommentManagerを作成
-	commentMgr, err := comment.NewCommentManager(client)
+	commentMgr, err := clients.CommentManager()
 	if err != nil {
 		log.Fatalf("failed to create comment manager: %v", err)
 	}
@@ -42,24 +37,9 @@ func main() {
 		log.Fatal("GOOGLE_TEST_DOC_ID is required. Please set it in .env file or environment variable.")
 	}
 
-	// まず既存のコメントを削除
-	fmt.Println("既存のコメントを削除中...")
-	existingComments, err := commentMgr.ListComments(ctx, docID)
-	if err != nil {
-		log.Printf("警告: コメント一覧取得に失敗: %v", err)
-	} else {
-		for _, c := range existingComments {
-			if err := commentMgr.DeleteComment(ctx, docID, c.Id); err != nil {
-				log.Printf("警告: コメント削除に失敗 (ID: %s): %v", c.Id, err)
-			} else {
-				fmt.Printf("  削除: %s\n", c.Id)
-			}
-		}
-	}
-
 	fmt.Println("\n新しいアンカー付きレビューコメントを作成中...")
-	fmt.Println("注意: CreateAnchoredCommentはLineNumberベースのアンカーを使用しますが、")
-	fmt.Println("     Google Docs UIでは正しく表示されない可能性があります。")
+	fmt.Println("注意: LineNumberはfetch_google_docの行番号と同じ定義で行に解決され、その行のテキスト範囲にアンカーされます。")
+	fmt.Println("     Google Docs UIはAPIで付けたアンカーを表示しないことがあるため、引用テキストも付与します。")
 
 	// CreateAnchoredCommentを使ってアンカー付きコメントを作成
 	anchoredComments := []struct {
@@ -94,37 +74,52 @@ func main() {
 		},
 	}
 
-	successCount := 0
-	for i, ac := range anchoredComments {
-		req := &comment.CommentRequest{
+	requests := make([]*comment.CommentRequest, 0, len(anchoredComments))
+	for _, ac := range anchoredComments {
+		requests = append(requests, &comment.CommentRequest{
 			FileID:     docID,
 			Content:    ac.content,
 			QuotedText: ac.quotedText,
 			LineNumber: ac.lineNumber,
 			LineLength: 1,
-		}
+		})
+	}
+
+	// LineNumberが指定されたリクエストはCreateAnchoredCommentで作成される(解決した行のテキスト範囲へのアンカー)
+	// 既存コメントと重複するものはスキップし、既存コメントは削除しない
+	result, err := commentMgr.CreateMultipleComments(ctx, requests, &comment.BatchOptions{SkipDuplicates: true})
+	if err != nil {
+		log.Printf("警告: 一部のコメント作成に失敗しました: %v", err)
+	}
 
-		// CreateAnchoredCommentを使う(LineNumberベースのアンカー)
-		resp, err := commentMgr.CreateAnchoredComment(ctx, req)
-		if err != nil {
-			log.Printf("❌ コメント%d作成失敗: %v", i+1, err)
+	// 番号はリクエストの順番で表示する(スキップや失敗があってもずれないようにItemsを使う)
+	for _, item := range result.Items {
+		if item.Err != nil {
+			fmt.Printf("\n❌ コメント%d作成失敗: %v\n", item.Index+1, item.Err)
 			continue
 		}
-
-		successCount++
-		fmt.Printf("\n✅ コメント%d作成成功:\n", i+1)
+		if item.Response == nil {
+			continue
+		}
+		resp := item.Response
+		fmt.Printf("\n✅ コメント%d作成成功:\n", item.Index+1)
 		fmt.Printf("   ID: %s\n", resp.CommentID)
-		fmt.Printf("   行番号: %d\n", ac.lineNumber)
-		fmt.Printf("   引用テキスト: %s\n", ac.quotedText)
-		fmt.Printf("   Issue種別: %s\n", ac.issueType)
+		fmt.Printf("   行の内容: %s\n", resp.MatchedText)
 		fmt.Printf("   アンカー情報: %s\n", resp.Anchor)
 		fmt.Printf("   作成日時: %s\n", resp.CreatedAt)
 	}
 
+	// 既存コメントと重複してスキップしたものを表示
+	for _, skipped := range result.Skipped {
+		fmt.Printf("\n⏭️  コメント%dは既存コメントと重複するためスキップ:\n", skipped.Index+1)
+		fmt.Printf("   既存コメントID: %s (%s)\n", skipped.Duplicate.ID, skipped.Duplicate.Author.Name())
+		fmt.Printf("   類似度: %.2f\n", skipped.Similarity)
+	}
+
 	fmt.Printf("\n\n========================================\n")
-	fmt.Printf("✅ 完了: %d/%d件のアンカー付きコメントを作成しました\n", successCount, len(anchoredComments))
+	fmt.Printf("✅ 完了: %d/%d件のアンカー付きコメントを作成しました (重複スキップ: %d件)\n", len(result.Responses), len(anchoredComments), len(result.Skipped))
 	fmt.Printf("========================================\n")
 	fmt.Println("\n⚠️ 注意:")
-	fmt.Println("LineNumberベースのアンカーはGoogle Docs UIで正しく動作しない可能性があります。")
+	fmt.Println("APIで付けたアンカーはGoogle Docs UIで表示されない場合があります(引用テキストは表示されます)。")
 	fmt.Println("詳細は docs/reports/google-doc/comment-with-anchor.md を参照してください。")
 }
@@ -6,8 +6,8 @@ import (
 	"log"
 
 	"github.com/takeuchi-shogo/google-doc-review/config"
-	"github.com/takeuchi-shogo/google-doc-review/internal/authmanager"
 	"github.com/takeuchi-shogo/google-doc-review/internal/comment"
+	"github.com/takeuchi-shogo/google-doc-review/internal/googleclient"
 )
 
 func main() {
@@ -19,19 +19,14 @@ func main() {
 		log.Fatalf("failed to load config: %v", err)
 	}
 
-	// 認証してHTTPクライアントを取得
-	authMgr := authmanager.NewWithConfig(
-		cfg.Google.ClientID,
-		cfg.Google.ClientSecret,
-		&authmanager.BrowserAuthenticator{},
-	)
-	client, err := authMgr.GetOrAuthenticateClient(ctx)
+	// 認証し、Google APIのクライアント一式を作成
+	clients, err := googleclient.New(ctx, cfg)
 	if err != nil {
-		log.Fatalf("failed to get authenticated client: %v", err)
+		log.Fatalf("failed to create Google clients: %v", err)
 	}
 
 	// CommentManagerを作成
-	commentMgr, err := comment.NewCommentManager(client)
+	commentMgr, err := clients.CommentManager()
 	if err != nil {
 		log.Fatalf("failed to create comment manager: %v", err)
 	}
@@ -42,21 +37,6 @@ func main() {
 		log.Fatal("GOOGLE_TEST_DOC_ID is required. Please set it in .env file or environment variable.")
 	}
 
-	// まず既存のコメントを削除
-	fmt.Println("既存のコメントを削除中...")
-	existingComments, err := commentMgr.ListComments(ctx, docID)
-	if err != nil {
-		log.Printf("警告: コメント一覧取得に失敗: %v", err)
-	} else {
-		for _, c := range existingComments {
-			if err := commentMgr.DeleteComment(ctx, docID, c.Id); err != nil {
-				log.Printf("警告: コメント削除に失敗 (ID: %s): %v", c.Id, err)
-			} else {
-				fmt.Printf("  削除: %s\n", c.Id)
-			}
-		}
-	}
-
 	fmt.Println("\n新しいレビューコメントを作成中...")
 
 	// QuotedTextを使ってアンカー付きコメントを作成
@@ -87,30 +67,51 @@ func main() {
 		},
 	}
 
-	successCount := 0
-	for i, rc := range reviewComments {
-		req := &comment.CommentRequest{
+	requests := make([]*comment.CommentRequest, 0, len(reviewComments))
+	for _, rc := range reviewComments {
+		requests = append(requests, &comment.CommentRequest{
 			FileID:     docID,
 			Content:    rc.content,
 			QuotedText: rc.quotedText,
-		}
+		})
+	}
 
-		// CreateCommentを使う(内部でFindTextPositionを使ってアンカーを作成)
-		resp, err := commentMgr.CreateComment(ctx, req)
-		if err != nil {
-			log.Printf("❌ コメント%d作成失敗: %v", i+1, err)
+	// 既存コメントと重複するものはスキップして作成(内部でFindTextPositionを使ってアンカーを作成)
+	// 既存コメントは削除しないため、他のレビュアーのコメントも残る
+	result, err := commentMgr.CreateMultipleComments(ctx, requests, &comment.BatchOptions{SkipDuplicates: true})
+	if err != nil {
+		log.Printf("警告: 一部のコメント作成に失敗しました: %v", err)
+	}
+
+	// 番号はリクエストの順番で表示する(スキップや失敗があってもずれないようにItemsを使う)
+	for _, item := range result.Items {
+		if item.Err != nil {
+			fmt.Printf("\n❌ コメント%d作成失敗: %v\n", item.Index+1, item.Err)
 			continue
 		}
-
-		successCount++
-		fmt.Printf("\n✅ コメント%d作成成功:\n", i+1)
+		if item.Response == nil {
+			continue
+		}
+		resp := item.Response
+		fmt.Printf("\n✅ コメント%d作成成功:\n", item.Index+1)
 		fmt.Printf("   ID: %s\n", resp.CommentID)
-		fmt.Printf("   引用テキスト: %s\n", rc.quotedText)
+		fmt.Printf("   引用テキスト: %s\n", resp.MatchedText)
 		fmt.Printf("   アンカー: %s\n", resp.Anchor)
+		fmt.Printf("   アンカー状態: %s\n", resp.AnchorStatus)
+		if resp.AnchorReason != "" {
+			fmt.Printf("   理由: %s\n", resp.AnchorReason)
+		}
 		fmt.Printf("   作成日時: %s\n", resp.CreatedAt)
 	}
 
+	// 既存コメントと重複してスキップしたものを表示
+	for _, skipped := range result.Skipped {
+		fmt.Printf("\n⏭️  コメント%dは既存コメントと重複するためスキップ:\n", skipped.Index+1)
+		fmt.Printf("   既存コメントID: %s (%s)\n", skipped.Duplicate.ID, skipped.Duplicate.Author.Name())
+		fmt.Printf("   類似度: %.2f\n", skipped.Similarity)
+	}
+
 	fmt.Printf("\n\n========================================\n")
-	fmt.Printf("✅ 完了: %d/%d件のコメントを作成しました\n", successCount, len(reviewComments))
+	fmt.Printf("✅ 完了: %d/%d件のコメントを作成しました (重複スキップ: %d件)\n", len(result.Responses), len(reviewComments), len(result.Skipped))
 	fmt.Printf("========================================\n")
 }
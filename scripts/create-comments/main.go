@@ -6,8 +6,8 @@ import (
 	"log"
 
 	"github.com/takeuchi-shogo/google-doc-review/config"
-	"github.com/takeuchi-shogo/google-doc-review/internal/authmanager"
 	"github.com/takeuchi-shogo/google-doc-review/internal/comment"
+	"github.com/takeuchi-shogo/google-doc-review/internal/googleclient"
 )
 
 func main() {
@@ -19,19 +19,14 @@ func main() {
 		log.Fatalf("failed to load config: %v", err)
 	}
 
-	// 認証してHTTPクライアントを取得
-	authMgr := authmanager.NewWithConfig(
-		cfg.Google.ClientID,
-		cfg.Google.ClientSecret,
-		&authmanager.BrowserAuthenticator{},
-	)
-	client, err := authMgr.GetOrAuthenticateClient(ctx)
+	// 認証し、Google APIのクライアント一式を作成
+	clients, err := googleclient.New(ctx, cfg)
 	if err != nil {
-		log.Fatalf("failed to get authenticated client: %v", err)
+		log.Fatalf("failed to create Google clients: %v", err)
 	}
 
 	// CommentManagerを作成
-	commentMgr, err := comment.NewCommentManager(client)
+	commentMgr, err := clients.CommentManager()
 	if err != nil {
 		log.Fatalf("failed to create comment manager: %v", err)
 	}
@@ -78,17 +73,27 @@ func main() {
 		},
 	}
 
-	// コメントを作成
-	responses, err := commentMgr.CreateCommentsFromIssues(ctx, docID, issues)
+	// コメントを作成（既存コメントと重複するものはスキップ）
+	result, err := commentMgr.CreateCommentsFromIssues(ctx, docID, issues, &comment.BatchOptions{SkipDuplicates: true})
+	if result == nil {
+		log.Fatalf("failed to create comments: %v", err)
+	}
 	if err != nil {
 		log.Printf("警告: 一部のコメント作成に失敗しました: %v", err)
 	}
 
 	// 結果を表示
-	fmt.Printf("\n✅ %d件のレビューコメントを作成しました:\n\n", len(responses))
-	for i, resp := range responses {
+	fmt.Printf("\n✅ %d件のレビューコメントを作成しました:\n\n", len(result.Responses))
+	for i, resp := range result.Responses {
 		fmt.Printf("%d. Comment ID: %s\n", i+1, resp.CommentID)
 		fmt.Printf("   内容: %s\n", resp.Content)
 		fmt.Printf("   作成日時: %s\n\n", resp.CreatedAt)
 	}
+
+	// 既存コメントと重複してスキップしたものを表示
+	for _, skipped := range result.Skipped {
+		fmt.Printf("\n⏭️  コメント%dは既存コメントと重複するためスキップ:\n", skipped.Index+1)
+		fmt.Printf("   既存コメントID: %s (%s)\n", skipped.Duplicate.ID, skipped.Duplicate.Author.Name())
+		fmt.Printf("   類似度: %.2f\n", skipped.Similarity)
+	}
 }
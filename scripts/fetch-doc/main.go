@@ -6,8 +6,7 @@ import (
 	"log"
 
 	"github.com/takeuchi-shogo/google-doc-review/config"
-	"github.com/takeuchi-shogo/google-doc-review/internal/authmanager"
-	"github.com/takeuchi-shogo/google-doc-review/internal/review"
+	"github.com/takeuchi-shogo/google-doc-review/internal/googleclient"
 )
 
 func main() {
@@ -19,19 +18,14 @@ func main() {
 		log.Fatalf("failed to load config: %v", err)
 	}
 
-	// 認証してHTTPクライアントを取得
-	authMgr := authmanager.NewWithConfig(
-		cfg.Google.ClientID,
-		cfg.Google.ClientSecret,
-		&authmanager.BrowserAuthenticator{},
-	)
-	client, err := authMgr.GetOrAuthenticateClient(ctx)
+	// 認証し、Google APIのクライアント一式を作成
+	clients, err := googleclient.New(ctx, cfg)
 	if err != nil {
-		log.Fatalf("failed to get authenticated client: %v", err)
+		log.Fatalf("failed to create Google clients: %v", err)
 	}
 
 	// GoogleDocFetcherを作成
-	fetcher := review.NewGoogleDocFetcher(client)
+	fetcher := clients.Fetcher()
 
 	// ドキュメントIDを設定から取得してURLを構築
 	docID := cfg.Google.TestDocID
@@ -41,7 +35,7 @@ func main() {
 	docURL := fmt.Sprintf("https://docs.google.com/document/d/%s/edit", docID)
 
 	// ドキュメントを取得
-	doc, err := fetcher.FetchDocument(ctx, docURL)
+	doc, err := fetcher.FetchDocument(ctx, docURL, nil)
 	if err != nil {
 		log.Fatalf("failed to fetch document: %v", err)
 	}